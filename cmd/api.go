@@ -0,0 +1,345 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"kubelearn/pkg/audit"
+	"kubelearn/pkg/auth"
+	"kubelearn/pkg/history"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/openapi"
+	"kubelearn/pkg/output"
+	"kubelearn/pkg/policy"
+	"kubelearn/pkg/provision"
+	"kubelearn/pkg/recording"
+	"kubelearn/pkg/registry"
+	"kubelearn/pkg/session"
+
+	"github.com/gorilla/mux"
+)
+
+// route is an operation of the API: its handler and its description in
+// the OpenAPI document.
+type route struct {
+	openapi.Route
+	handler http.HandlerFunc
+}
+
+// apiDeps are what the handlers of the API work with. The handlers may be
+// built without them, e.g. to describe the API.
+type apiDeps struct {
+	users *auth.Store
+	// setupJob is nil when the environment cannot be set up from the
+	// backend; the setup routes are left out then.
+	setupJob   *provision.Job
+	sessions   *session.Manager
+	store      *history.Store
+	recordings *recording.Store
+	commands   *audit.Store
+	start      startShell
+	policy     *policy.Policy
+	// clients grade and provision the questions outside of sessions.
+	clients *k8s.Clients
+}
+
+// apiRoutes lists the operations of the API, relative to apiPrefix. Paths
+// take gorilla/mux patterns.
+func apiRoutes(d apiDeps) []route {
+	sessionParam := openapi.Parameter{Name: "session", In: "query", Description: "ID of a quiz session", Schema: &openapi.Schema{Type: "string"}}
+	userParam := openapi.Parameter{Name: "user", In: "query", Description: "user whose data administrators read; everyone's by default", Schema: &openapi.Schema{Type: "string"}}
+	var resultTypes []string
+	for _, name := range output.Names() {
+		if formatter, _ := output.Get(name, false); name != "json" {
+			resultTypes = append(resultTypes, formatter.ContentType())
+		}
+	}
+
+	routes := []route{
+		// Accounts
+		{openapi.Route{
+			ID: "login", Method: http.MethodPost, Path: "/login", Tag: "accounts",
+			Summary:     "Log in",
+			Description: "Exchanges a username and password for a token, also set as an HTTP-only cookie.",
+			Request:     credentials{}, Response: loginResponse{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized},
+			Public: true,
+		}, login(d.users)},
+		{openapi.Route{
+			ID: "logout", Method: http.MethodPost, Path: "/logout", Tag: "accounts",
+			Summary: "Revoke the token of the request",
+			Status:  http.StatusNoContent,
+		}, logout(d.users)},
+		{openapi.Route{
+			ID: "getCurrentUser", Method: http.MethodGet, Path: "/me", Tag: "accounts",
+			Summary:  "Get the account of the request",
+			Response: account{},
+		}, currentUser},
+		{openapi.Route{
+			ID: "createUser", Method: http.MethodPost, Path: "/users", Tag: "accounts",
+			Summary: "Create an account", Description: "Only administrators may create accounts.",
+			Request: credentials{}, Response: auth.User{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusConflict},
+		}, adminOnly(createUser(d.users))},
+
+		// Questions
+		{openapi.Route{
+			ID: "listQuestions", Method: http.MethodGet, Path: "/questions", Tag: "questions",
+			Summary:  "List the questions without grading them",
+			Response: []registry.Info{},
+		}, listQuestions},
+		{openapi.Route{
+			ID: "getQuestion", Method: http.MethodGet, Path: "/questions/{id:[0-9]+}", Tag: "questions",
+			Summary:  "Get a question without grading it",
+			Response: registry.Info{},
+			Errors:   []int{http.StatusNotFound},
+		}, getQuestion},
+		{openapi.Route{
+			ID: "checkQuestion", Method: http.MethodPost, Path: "/questions/{id:[0-9]+}/check", Tag: "questions",
+			Summary:     "Grade a question",
+			Description: "With a session, the check is recorded as an attempt of that quiz session.",
+			Params:      []openapi.Parameter{sessionParam},
+			Response:    session.Attempt{},
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+		}, checkQuestion(d.clients, d.sessions)},
+		{openapi.Route{
+			ID: "runScenario", Method: http.MethodPost, Path: "/questions/{id:[0-9]+}/scenario/{action:setup|reset|teardown}", Tag: "questions",
+			Summary:     "Set up, reset or tear down the scenario of a question",
+			Description: "Only administrators may run it, unless namespaces are isolated; learners then act on their own namespaces.",
+			Params:      []openapi.Parameter{{Name: "action", In: "path", Description: "setup, reset or teardown"}},
+			Response:    scenarioResult{},
+			Errors:      []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		}, adminUnlessIsolated(handleScenario(d.clients))},
+		{openapi.Route{
+			ID: "runScenarios", Method: http.MethodPost, Path: "/questions/scenario/{action:setup|reset|teardown}", Tag: "questions",
+			Summary:     "Set up, reset or tear down the scenarios of every question",
+			Description: "Only administrators may run it, unless namespaces are isolated; learners then act on their own namespaces.",
+			Params:      []openapi.Parameter{{Name: "action", In: "path", Description: "setup, reset or teardown"}},
+			Response:    scenarioResult{},
+			Errors:      []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError},
+		}, adminUnlessIsolated(handleScenario(d.clients))},
+
+		// Quiz sessions
+		{openapi.Route{
+			ID: "startSession", Method: http.MethodPost, Path: "/sessions", Tag: "sessions",
+			Summary:     "Start a timed quiz session",
+			Description: "The body may select the questions; by default every question is selected.",
+			Request:     startRequest{}, Response: session.Session{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest},
+		}, startQuiz(d.sessions)},
+		{openapi.Route{
+			ID: "getSession", Method: http.MethodGet, Path: "/sessions/{id}", Tag: "sessions",
+			Summary:  "Get the state, remaining time and attempts of a session",
+			Response: session.Session{},
+			Errors:   []int{http.StatusNotFound},
+		}, getSession(d.sessions)},
+		{openapi.Route{
+			ID: "finishSession", Method: http.MethodPost, Path: "/sessions/{id}/finish", Tag: "sessions",
+			Summary:     "Grade and score a session",
+			Description: "The format follows the output parameter or the Accept header. Sessions whose time ran out return the results graded at the deadline.",
+			Params: []openapi.Parameter{
+				{Name: "output", In: "query", Description: "result format, one of json, junit, tap, markdown or table", Schema: &openapi.Schema{Type: "string"}},
+			},
+			Response: quizResults{}, ContentTypes: resultTypes,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusNotAcceptable, http.StatusConflict},
+		}, finishQuiz(d.sessions)},
+
+		// Score history
+		{openapi.Route{
+			ID: "listAttempts", Method: http.MethodGet, Path: "/history", Tag: "history",
+			Summary:  "List the finished sessions, newest first",
+			Params:   []openapi.Parameter{userParam},
+			Response: []history.Summary{},
+		}, listAttempts(d.store)},
+		// Before /history/{id}, which would match it
+		{openapi.Route{
+			ID: "getPassRates", Method: http.MethodGet, Path: "/history/stats", Tag: "history",
+			Summary:  "Get the pass rate of each question overall and per day",
+			Params:   []openapi.Parameter{userParam},
+			Response: []history.QuestionStats{},
+		}, getPassRates(d.store)},
+		{openapi.Route{
+			ID: "getAttempt", Method: http.MethodGet, Path: "/history/{id}", Tag: "history",
+			Summary:  "Get a finished session with its per-question results",
+			Response: history.Attempt{},
+			Errors:   []int{http.StatusNotFound},
+		}, getAttempt(d.store)},
+
+		// Terminals
+		{openapi.Route{
+			ID: "openTerminal", Method: http.MethodGet, Path: "/terminal", Tag: "terminals",
+			Summary:     "Open a terminal over a WebSocket",
+			Description: "The session links the commands and the recording to a quiz session.",
+			Params: []openapi.Parameter{
+				sessionParam,
+				{Name: "cols", In: "query", Schema: &openapi.Schema{Type: "integer"}},
+				{Name: "rows", In: "query", Schema: &openapi.Schema{Type: "integer"}},
+			},
+			Status: http.StatusSwitchingProtocols,
+			Errors: []int{http.StatusNotFound},
+		}, handleTerminal(d.start, d.policy, d.sessions, d.recordings, d.commands)},
+		{openapi.Route{
+			ID: "listRecordings", Method: http.MethodGet, Path: "/recordings", Tag: "terminals",
+			Summary:  "List the terminal recordings, newest first",
+			Params:   []openapi.Parameter{userParam, sessionParam},
+			Response: []recording.Recording{},
+			Errors:   []int{http.StatusNotFound},
+		}, recordingsEnabled(d.recordings, listRecordings(d.recordings))},
+		{openapi.Route{
+			ID: "downloadRecording", Method: http.MethodGet, Path: "/recordings/{id}", Tag: "terminals",
+			Summary:      "Download the asciicast file of a recording",
+			ContentTypes: []string{"application/x-asciicast"},
+			Errors:       []int{http.StatusNotFound},
+		}, recordingsEnabled(d.recordings, downloadRecording(d.recordings))},
+		{openapi.Route{
+			ID: "replayRecording", Method: http.MethodGet, Path: "/recordings/{id}/replay", Tag: "terminals",
+			Summary: "Replay a recording over a WebSocket",
+			Params: []openapi.Parameter{
+				{Name: "speed", In: "query", Schema: &openapi.Schema{Type: "number"}},
+				{Name: "idle", In: "query", Description: "longest pause in seconds", Schema: &openapi.Schema{Type: "number"}},
+			},
+			Status: http.StatusSwitchingProtocols,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+		}, recordingsEnabled(d.recordings, replayRecording(d.recordings))},
+		{openapi.Route{
+			ID: "listCommands", Method: http.MethodGet, Path: "/audit", Tag: "terminals",
+			Summary:  "List the commands run in the terminals, oldest first",
+			Params:   []openapi.Parameter{userParam, sessionParam},
+			Response: []audit.Command{},
+		}, listCommands(d.commands)},
+		{openapi.Route{
+			ID: "getCommandSummary", Method: http.MethodGet, Path: "/sessions/{id}/audit", Tag: "terminals",
+			Summary:  "Measure the commands of a session per question",
+			Response: audit.Summary{},
+			Errors:   []int{http.StatusNotFound},
+		}, commandSummary(d.commands, d.sessions)},
+	}
+
+	// Setup creates a kind cluster, which is not possible in a cluster
+	if d.setupJob != nil {
+		routes = append(routes,
+			route{openapi.Route{
+				ID: "startSetup", Method: http.MethodPost, Path: "/setup", Tag: "setup",
+				Summary:     "Provision the cluster and the scenarios",
+				Description: "Starts the setup job unless it is running; answers 200 instead of 202 when it was.",
+				Response:    provision.Status{}, Status: http.StatusAccepted,
+				Errors: []int{http.StatusForbidden},
+			}, adminOnly(setupEnvironment(d.setupJob))},
+			route{openapi.Route{
+				ID: "getSetupStatus", Method: http.MethodGet, Path: "/setup/status", Tag: "setup",
+				Summary:  "Get the state and logs of the setup job",
+				Response: provision.Status{},
+			}, setupStatus(d.setupJob)},
+			route{openapi.Route{
+				ID: "streamSetupEvents", Method: http.MethodGet, Path: "/setup/events", Tag: "setup",
+				Summary:      "Stream the setup progress as Server-Sent Events",
+				ContentTypes: []string{"text/event-stream"},
+			}, setupEvents(d.setupJob)},
+		)
+	}
+
+	routes = append(routes, route{Route: openapi.Route{
+		ID: "getOpenAPI", Method: http.MethodGet, Path: "/openapi.json", Tag: "api",
+		Summary: "Get this OpenAPI document",
+		Public:  true,
+	}})
+	spec := &routes[len(routes)-1]
+	spec.handler = serveDocument(apiDocument(routes))
+	return routes
+}
+
+// apiDocument describes the routes of the API.
+func apiDocument(routes []route) *openapi.Document {
+	operations := make([]openapi.Route, len(routes))
+	for i, route := range routes {
+		operations[i] = route.Route
+	}
+	generator := openapi.Generator{
+		Info: openapi.Info{
+			Title:       "KubeLearn",
+			Description: "Quizzes graded on a Kubernetes cluster, and the terminals to solve them.",
+			Version:     "v1",
+		},
+		Servers: []openapi.Server{{URL: apiPrefix}},
+		Security: map[string]openapi.SecurityScheme{
+			"bearer": {Type: "http", Scheme: "bearer", Description: "token returned by POST /login"},
+			"cookie": {Type: "apiKey", In: "cookie", Name: auth.CookieName, Description: "cookie set by POST /login"},
+		},
+		Error: errorResponse{},
+	}
+	return generator.Generate(operations)
+}
+
+func serveDocument(doc *openapi.Document) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(doc)
+	}
+}
+
+// newAPI routes the API. Every route but the public ones requires an
+// authenticated user, whose exercise namespaces are prefixed with the
+// username when isolate is set.
+func newAPI(routes []route, users *auth.Store, isolate bool) http.Handler {
+	router := mux.NewRouter()
+	// Paths are cleaned with a redirect otherwise, which would lose the
+	// API prefix.
+	router.SkipClean(true)
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, "no such endpoint", http.StatusNotFound)
+	})
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+	})
+	for _, route := range routes {
+		var handler http.Handler = route.handler
+		if !route.Public {
+			if isolate {
+				handler = isolateNamespaces(handler)
+			}
+			handler = requireUser(users, handler)
+		}
+		router.Handle(route.Path, handler).Methods(route.Method).Name(route.ID)
+	}
+	return router
+}
+
+// errorResponse is the body of every error response of the API.
+type errorResponse struct {
+	Status int `json:"status"`
+	// Error is the text of the status, e.g. Not Found.
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// writeError replies with a JSON error, e.g.
+// {"status": 404, "error": "Not Found", "message": "session not found"}.
+func writeError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Status: status, Error: http.StatusText(status), Message: message})
+}
+
+// pathID returns the integer id path parameter of a request.
+func pathID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, "id must be a number", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// recordingsEnabled answers 404 instead of calling next when terminal
+// recording is disabled.
+func recordingsEnabled(recordings *recording.Store, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if recordings == nil {
+			writeError(w, "terminal recording is disabled", http.StatusNotFound)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"kubelearn/pkg/audit"
+	"kubelearn/pkg/session"
+
+	"github.com/gorilla/mux"
+)
+
+// listCommands lists the commands run in the terminals, oldest first. The
+// session query parameter selects the commands of one quiz session.
+func listCommands(commands *audit.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := commands.List(historyUser(r), r.URL.Query().Get("session"))
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// commandSummary measures the commands of a quiz session, attributed to
+// the questions they were run for, e.g. GET /sessions/ID/audit. The
+// session may be running or graded.
+func commandSummary(commands *audit.Store, sessions *session.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+		// Graded sessions are read back from the history.
+		s, err := sessions.Get(id)
+		if err == nil && !canAccess(r, s.User) {
+			err = session.ErrNotFound
+		}
+		if err != nil {
+			sessionError(w, err)
+			return
+		}
+
+		list, err := commands.List(s.User, id)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(audit.Summarize(id, list, s.Attempts, s.Results))
+	}
+}
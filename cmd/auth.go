@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"kubelearn/pkg/auth"
+	"kubelearn/pkg/k8s"
+)
+
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Admin    bool   `json:"admin"`
+}
+
+// loginResponse is the body of a successful login.
+type loginResponse struct {
+	Token string    `json:"token"`
+	User  auth.User `json:"user"`
+}
+
+// account is the body of GET /me.
+type account struct {
+	auth.User
+	NamespacePrefix string `json:"namespacePrefix,omitempty"`
+}
+
+// login exchanges a username and password for a token. The token is
+// returned in the body for API clients and set as an HTTP-only cookie for
+// the browser.
+func login(users *auth.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req credentials
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		token, user, err := users.Login(req.Username, req.Password)
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			writeError(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     auth.CookieName,
+			Value:    token,
+			Path:     "/",
+			MaxAge:   int(auth.DefaultTokenTTL.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(loginResponse{Token: token, User: user})
+	}
+}
+
+// logout revokes the token of the request and clears the cookie.
+func logout(users *auth.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := users.Logout(auth.Token(r)); err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: auth.CookieName, Value: "", Path: "/", MaxAge: -1})
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// currentUser returns the account of the request and the prefix of its
+// exercise namespaces.
+func currentUser(w http.ResponseWriter, r *http.Request) {
+	user, _ := auth.FromContext(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(account{user, k8s.NamespacePrefix(r.Context())})
+}
+
+// createUser adds an account. Only administrators may call it.
+func createUser(users *auth.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req credentials
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		user, err := users.CreateUser(req.Username, req.Password, req.Admin)
+		if errors.Is(err, auth.ErrUserExists) {
+			writeError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(user)
+	}
+}
+
+// requireUser rejects requests without a valid token and adds the user to
+// the context of the others.
+func requireUser(users *auth.Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := users.Authenticate(auth.Token(r))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kubelearn"`)
+			writeError(w, auth.ErrUnauthenticated.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+	})
+}
+
+// adminOnly rejects requests from users who are not administrators.
+func adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if user, ok := auth.FromContext(r.Context()); !ok || !user.Admin {
+			writeError(w, "administrator access required", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// adminUnlessIsolated rejects requests from users who are not
+// administrators unless the namespaces of the request are isolated, so that
+// learners only act on their own copies of the exercise namespaces.
+func adminUnlessIsolated(next http.HandlerFunc) http.HandlerFunc {
+	admin := adminOnly(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if k8s.NamespacePrefix(r.Context()) != "" {
+			next(w, r)
+			return
+		}
+		admin(w, r)
+	}
+}
+
+// isolateNamespaces maps the exercise namespaces of every request to
+// namespaces prefixed with the username, e.g. colors to alice-colors.
+// Accounts whose prefix would map them to reserved namespaces are refused.
+func isolateNamespaces(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, ok := auth.FromContext(r.Context()); ok {
+			if err := k8s.CheckNamespacePrefix(user.Username); err != nil {
+				writeError(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			r = r.WithContext(k8s.WithNamespacePrefix(r.Context(), user.Username))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// canAccess reports whether the user of the request may see data owned by
+// owner; administrators may see everything.
+func canAccess(r *http.Request, owner string) bool {
+	user, ok := auth.FromContext(r.Context())
+	return ok && (user.Admin || user.Username == owner)
+}
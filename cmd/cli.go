@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"kubelearn/pkg/declarative"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/output"
+	"kubelearn/pkg/provision"
+	"kubelearn/pkg/registry"
+	"kubelearn/pkg/scoring"
+	"kubelearn/pkg/utils"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// errFailed makes the command exit with a non-zero status after it has
+// reported the failure itself, e.g. a failed check.
+var errFailed = errors.New("failed")
+
+func newRootCommand() *cobra.Command {
+	var (
+		questionsDir string
+		kube         k8s.ConfigOptions
+	)
+	cmd := &cobra.Command{
+		Use:   "kubelearn",
+		Short: "Practice for the Kubernetes exams on a real cluster",
+		Long: "KubeLearn grades tasks solved on a Kubernetes cluster. Use check to grade\n" +
+			"yourself from the terminal, or serve to run the web frontend's backend.",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return loadQuestions(questionsDir)
+		},
+	}
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&questionsDir, "questions-dir", "", "directory with YAML or JSON question definitions to load")
+	flags.StringVar(&kube.Kubeconfig, "kubeconfig", "", "path to the kubeconfig file; defaults to $KUBECONFIG or ~/.kube/config, then to the in-cluster service account")
+	flags.StringVar(&kube.Context, "context", "", "kubeconfig context to use instead of the current one")
+	cmd.AddCommand(newCheckCommand(&kube), newListCommand(), newSetupCommand(&kube), newServeCommand(&kube), newOpenAPICommand())
+	return cmd
+}
+
+func newCheckCommand(kube *k8s.ConfigOptions) *cobra.Command {
+	var (
+		questions       []int
+		scoringConfig   string
+		namespacePrefix string
+		format          string
+	)
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Grade the questions against the cluster",
+		Long: "Grade every question, or those given with --question, against the cluster\n" +
+			"and show the results. The command fails unless every graded question passed.",
+		Example: "  kubelearn check\n  kubelearn check --question 11 --question 4\n  kubelearn check --output junit > results.xml",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			formatter, err := output.Get(format, true)
+			if err != nil {
+				return err
+			}
+			selected, err := selectQuestions(questions)
+			if err != nil {
+				return err
+			}
+			cfg, err := loadScoring(scoringConfig)
+			if err != nil {
+				return err
+			}
+			config, err := k8s.LoadKubeConfig(*kube)
+			if err != nil {
+				return err
+			}
+			clients, err := k8s.NewClients(config)
+			if err != nil {
+				return fmt.Errorf("creating Kubernetes clients: %w", err)
+			}
+
+			ctx := k8s.WithNamespacePrefix(cmd.Context(), namespacePrefix)
+			var results []utils.Result
+			for _, q := range selected {
+				results = append(results, q.Check(ctx, clients))
+			}
+			report := scoring.Score(cfg, results)
+			if err := formatter.Format(cmd.OutOrStdout(), results, &report); err != nil {
+				return err
+			}
+			for _, result := range results {
+				if !result.Passed {
+					return errFailed
+				}
+			}
+			return nil
+		},
+	}
+	flags := cmd.Flags()
+	flags.IntSliceVarP(&questions, "question", "q", nil, "ID of a question to grade; may be repeated")
+	flags.StringVar(&scoringConfig, "scoring-config", "", "path to a JSON file with difficulty weights and the pass threshold")
+	flags.StringVar(&namespacePrefix, "namespace-prefix", "", "grade the exercise namespaces prefixed for a user, as with serve --isolate-namespaces")
+	flags.StringVarP(&format, "output", "o", "table", "output format: "+strings.Join(output.Names(), ", "))
+	return cmd
+}
+
+func newListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the questions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			table := tablewriter.NewWriter(cmd.OutOrStdout())
+			table.SetHeader([]string{"ID", "Question", "Difficulty", "Namespace", "Tags"})
+			table.SetAutoWrapText(false)
+			for _, info := range registry.List() {
+				table.Append([]string{strconv.Itoa(info.ID), info.Prompt, info.Difficulty, info.Namespace, strings.Join(info.Tags, ", ")})
+			}
+			table.Render()
+			return nil
+		},
+	}
+}
+
+func newSetupCommand(kube *k8s.ConfigOptions) *cobra.Command {
+	var opts provision.Options
+	cmd := &cobra.Command{
+		Use:   "setup",
+		Short: "Create the kind cluster and provision the scenarios",
+		Long: "Create the kind cluster, or reuse it, and provision the scenarios. kind writes\n" +
+			"the cluster credentials to the file given by --kubeconfig.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.KubeconfigPath = kube.Kubeconfig
+			opts.Reporter = func(event provision.Event) {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", color.CyanString("[%s]", event.Step), event.Message)
+			}
+			if err := provision.New(opts).Run(cmd.Context()); err != nil {
+				// The failure was reported as an event.
+				return errFailed
+			}
+			return nil
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&opts.ClusterName, "name", provision.DefaultClusterName, "name of the kind cluster")
+	flags.StringVar(&opts.NodeImage, "image", provision.DefaultNodeImage, "node image of the kind cluster")
+	return cmd
+}
+
+func newOpenAPICommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "openapi",
+		Short: "Print the OpenAPI document of the API served by serve",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(apiDocument(apiRoutes(apiDeps{setupJob: provision.NewJob(provision.Options{})})))
+		},
+	}
+}
+
+// loadQuestions adds the question definitions in dir to the registry.
+func loadQuestions(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	questions, err := declarative.LoadDir(dir)
+	if err != nil {
+		return fmt.Errorf("loading questions: %w", err)
+	}
+	for _, q := range questions {
+		if err := registry.Add(q); err != nil {
+			return fmt.Errorf("loading questions: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadScoring reads the scoring configuration at path, or returns the
+// default one when path is empty.
+func loadScoring(path string) (scoring.Config, error) {
+	if path == "" {
+		return scoring.DefaultConfig(), nil
+	}
+	cfg, err := scoring.LoadConfig(path)
+	if err != nil {
+		return cfg, fmt.Errorf("loading scoring configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// selectQuestions returns the questions with the given IDs, or every
+// question when none are given.
+func selectQuestions(ids []int) ([]registry.Question, error) {
+	if len(ids) == 0 {
+		return registry.All(), nil
+	}
+	var selected []registry.Question
+	for _, id := range ids {
+		q, ok := registry.Get(id)
+		if !ok {
+			return nil, fmt.Errorf("question %d not found; see kubelearn list", id)
+		}
+		selected = append(selected, q)
+	}
+	return selected, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// serveFrontend serves the production build of the React app. Paths that
+// are not files of the build, e.g. /quiz, get index.html so the app can
+// route them itself.
+func serveFrontend(build fs.FS) http.Handler {
+	files := http.FileServer(http.FS(build))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if name == "" {
+			name = "."
+		}
+		_, err := fs.Stat(build, name)
+		if err != nil && path.Ext(name) == "" {
+			r2 := r.Clone(r.Context())
+			r2.URL.Path = "/"
+			files.ServeHTTP(w, r2)
+			return
+		}
+		if err == nil && strings.HasPrefix(name, "static/") {
+			// The build fingerprints the names of its assets.
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"kubelearn/pkg/auth"
+	"kubelearn/pkg/history"
+
+	"github.com/gorilla/mux"
+)
+
+// historyUser returns whose history a request reads: learners see their own
+// attempts, administrators everyone's or those of the user query parameter.
+func historyUser(r *http.Request) string {
+	user, _ := auth.FromContext(r.Context())
+	if user.Admin {
+		return r.URL.Query().Get("user")
+	}
+	return user.Username
+}
+
+// listAttempts lists the finished quiz sessions, newest first.
+func listAttempts(store *history.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		attempts, err := store.List(historyUser(r))
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(attempts)
+	}
+}
+
+// getAttempt returns one finished session with its per-question results,
+// e.g. GET /history/ID.
+func getAttempt(store *history.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		attempt, err := store.Get(mux.Vars(r)["id"])
+		if err == nil && !canAccess(r, attempt.User) {
+			err = history.ErrNotFound
+		}
+		if errors.Is(err, history.ErrNotFound) {
+			writeError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(attempt)
+	}
+}
+
+// getPassRates returns the pass rate of each question overall and per day.
+func getPassRates(store *history.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := store.PassRates(historyUser(r))
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+	}
+}
@@ -15,64 +15,31 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"os/exec"
-	"strings"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
 
-	"kubelearn/pkg/k8s"
-	"kubelearn/pkg/resources/easy"
-	"kubelearn/pkg/resources/hard"
-	"kubelearn/pkg/resources/medium"
-	"kubelearn/pkg/utils"
+	"kubelearn/pkg/auth"
+	_ "kubelearn/pkg/resources/easy"
+	_ "kubelearn/pkg/resources/hard"
+	_ "kubelearn/pkg/resources/medium"
 
 	"github.com/gorilla/websocket"
-	"k8s.io/client-go/kubernetes"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
-}
-
-// runMakeCommand runs a Makefile target and logs the output.
-func runMakeCommand(target string) error {
-	cmd := exec.Command("make", target)
-	cmd.Dir = "../"
-	cmd.Stdout = log.Writer()
-	cmd.Stderr = log.Writer()
-	return cmd.Run()
-}
-
-// setupEnvironment triggers the setup process using the Makefile.
-func setupEnvironment(w http.ResponseWriter, r *http.Request) {
-	enableCors(&w)
-	go func() {
-		log.Println("Starting environment setup...")
-		if err := runMakeCommand("init"); err != nil {
-			log.Printf("Error initializing Terraform: %v", err)
-			return
-		}
-		if err := runMakeCommand("apply"); err != nil {
-			log.Printf("Error applying Terraform configurations: %v", err)
-			return
-		}
-		if err := runMakeCommand("all"); err != nil {
-			log.Printf("Error applying Kubernetes manifests: %v", err)
-		}
-	}()
+var upgrader = websocket.Upgrader{}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Environment setup started"))
-}
-
-// CORS middleware
-func withCORS(next http.Handler) http.Handler {
+// CORS middleware. Credentials are allowed, so only the frontend origin is
+// allowed instead of any origin.
+func withCORS(origin string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		enableCors(&w)
+		enableCors(&w, origin)
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
@@ -81,151 +48,53 @@ func withCORS(next http.Handler) http.Handler {
 	})
 }
 
-func enableCors(w *http.ResponseWriter) {
-	(*w).Header().Set("Access-Control-Allow-Origin", "*")
+func enableCors(w *http.ResponseWriter, origin string) {
+	(*w).Header().Set("Access-Control-Allow-Origin", origin)
+	(*w).Header().Set("Access-Control-Allow-Credentials", "true")
 	(*w).Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-	(*w).Header().Set("Access-Control-Allow-Headers", "Content-Type")
+	(*w).Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	(*w).Header().Add("Vary", "Origin")
 }
 
-func getQuestions(w http.ResponseWriter, clientset *kubernetes.Clientset) {
-	questions := []utils.Result{
-		easy.CreatePod(clientset),                       // Question 1 - Easy
-		medium.CreateDeployment(clientset),              // Question 2 - Medium
-		hard.CreateDeploymentAndService(clientset),      // Question 3 - Hard
-		easy.CreateNamespace(clientset),                 // Question 4 - Easy
-		medium.CreateConfigMap(clientset),               // Question 5 - Medium
-		medium.CreateLabel(clientset),                   // Question 6 - Medium
-		medium.CreatePersistentVolume(clientset),        // Question 7 - Medium
-		medium.CreatePersistentVolumeClaim(clientset),   // Question 8 - Medium
-		hard.CreatePodVolumeClaim(clientset),            // Question 9 - Hard
-		hard.CheckPodError(clientset),                   // Question 10 - Hard
-		hard.CreateNetPolRule(clientset),                // Question 11 - Hard
-		easy.CreateSecret(clientset),                    // Question 12 - Easy
-		hard.CreatePodAddSecret(clientset),              // Question 13 - Hard
-		easy.CreateServiceAccount(clientset),            // Question 14 - Easy
-		medium.AddServiceAccountToDeployment(clientset), // Question 15 - Medium
-		medium.ChangeReplicaCount(clientset),            // Question 16 - Medium
-		medium.CreateHpa(clientset),                     // Question 17 - Medium
-		medium.AddSecurityContext(clientset),            // Question 18 - Medium
-		medium.AddLivenessProbe(clientset),              // Question 19 - Medium
-		easy.CreateDeploymentYellow(clientset),          // Question 20 - Easy
-		hard.CreateServiceForYellow(clientset),          // Question 21 - Hard
-		hard.CreateIngressYellow(clientset),             // Question 22 - Hard
-		hard.CreateRoleOne(clientset),                   // Question 23 - Hard
-		medium.CreateJob(clientset),                     // Question 24 - Medium
-		medium.CreateCronjob(clientset),                 // Question 25 - Medium
-		hard.CreateStatefulSet(clientset),               // Question 26 - Hard
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(questions)
-}
-
-func startQuiz(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Quiz started"))
-}
-
-func finishQuiz(w http.ResponseWriter, clientset *kubernetes.Clientset) {
-	questions := []utils.Result{
-		easy.CreatePod(clientset),                       // Question 1 - Easy
-		medium.CreateDeployment(clientset),              // Question 2 - Medium
-		hard.CreateDeploymentAndService(clientset),      // Question 3 - Hard
-		easy.CreateNamespace(clientset),                 // Question 4 - Easy
-		medium.CreateConfigMap(clientset),               // Question 5 - Medium
-		medium.CreateLabel(clientset),                   // Question 6 - Medium
-		medium.CreatePersistentVolume(clientset),        // Question 7 - Medium
-		medium.CreatePersistentVolumeClaim(clientset),   // Question 8 - Medium
-		hard.CreatePodVolumeClaim(clientset),            // Question 9 - Hard
-		hard.CheckPodError(clientset),                   // Question 10 - Hard
-		hard.CreateNetPolRule(clientset),                // Question 11 - Hard
-		easy.CreateSecret(clientset),                    // Question 12 - Easy
-		hard.CreatePodAddSecret(clientset),              // Question 13 - Hard
-		easy.CreateServiceAccount(clientset),            // Question 14 - Easy
-		medium.AddServiceAccountToDeployment(clientset), // Question 15 - Medium
-		medium.ChangeReplicaCount(clientset),            // Question 16 - Medium
-		medium.CreateHpa(clientset),                     // Question 17 - Medium
-		medium.AddSecurityContext(clientset),            // Question 18 - Medium
-		medium.AddLivenessProbe(clientset),              // Question 19 - Medium
-		easy.CreateDeploymentYellow(clientset),          // Question 20 - Easy
-		hard.CreateServiceForYellow(clientset),          // Question 21 - Hard
-		hard.CreateIngressYellow(clientset),             // Question 22 - Hard
-		hard.CreateRoleOne(clientset),                   // Question 23 - Hard
-		medium.CreateJob(clientset),                     // Question 24 - Medium
-		medium.CreateCronjob(clientset),                 // Question 25 - Medium
-		hard.CreateStatefulSet(clientset),               // Question 26 - Hard
-	}
-
-	correctAnswers := 0
-	for _, question := range questions {
-		if question.Passed {
-			correctAnswers++
+// checkOrigin accepts WebSocket connections from the backend itself and
+// from the frontend origin, so other sites cannot reuse the login cookie.
+func checkOrigin(allowed string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || origin == allowed {
+			return true
 		}
+		u, err := url.Parse(origin)
+		return err == nil && u.Host == r.Host
 	}
-	totalQuestions := len(questions)
-	score := (float64(correctAnswers) / float64(totalQuestions)) * 100
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"score": score,
-	})
 }
 
-// WebSocket terminal handler
-func handleWebSocketTerminal(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		log.Println("Error upgrading to WebSocket:", err)
-		return
+// bootstrapAdmin creates the first administrator when no account exists
+// yet, with the password from the KUBELEARN_ADMIN_PASSWORD variable.
+func bootstrapAdmin(users *auth.Store, username string) error {
+	exists, err := users.HasUsers()
+	if err != nil || exists {
+		return err
 	}
-	defer conn.Close()
-
-	var buffer strings.Builder
-
-	for {
-		_, msg, err := conn.ReadMessage()
-		if err != nil {
-			log.Println("Read error:", err)
-			break
-		}
-
-		buffer.WriteString(string(msg))
-
-		// Quando o buffer recebe um newline, processa o comando
-		if strings.Contains(buffer.String(), "\n") {
-			cmd := exec.Command("sh", "-c", buffer.String())
-			output, err := cmd.CombinedOutput()
-			if err != nil {
-				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf("Error: %v\n", err)))
-			} else {
-				conn.WriteMessage(websocket.TextMessage, output)
-			}
-			buffer.Reset()
-		}
+	password := os.Getenv("KUBELEARN_ADMIN_PASSWORD")
+	if password == "" {
+		log.Println("No user accounts exist; set KUBELEARN_ADMIN_PASSWORD to create the first administrator")
+		return nil
 	}
+	if _, err := users.CreateUser(username, password, true); err != nil {
+		return err
+	}
+	log.Printf("Created administrator %s", username)
+	return nil
 }
 
 func main() {
-	config := k8s.LoadKubeConfig()
-	clientset, err := k8s.NewClientSet(config)
-	if err != nil {
-		log.Fatalf("Error creating Kubernetes clientset: %v", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := newRootCommand().ExecuteContext(ctx); err != nil {
+		if !errors.Is(err, errFailed) {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		os.Exit(1)
 	}
-
-	http.HandleFunc("/setup", setupEnvironment)
-	http.HandleFunc("/questions", func(w http.ResponseWriter, r *http.Request) {
-		getQuestions(w, clientset)
-	})
-	http.HandleFunc("/start", startQuiz)
-	http.HandleFunc("/finish", func(w http.ResponseWriter, r *http.Request) {
-		finishQuiz(w, clientset)
-	})
-
-	// WebSocket endpoint for terminal
-	http.HandleFunc("/terminal", func(w http.ResponseWriter, r *http.Request) {
-		handleWebSocketTerminal(w, r)
-	})
-
-	// Start the server with CORS middleware applied globally
-	log.Fatal(http.ListenAndServe(":8083", withCORS(http.DefaultServeMux)))
 }
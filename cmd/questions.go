@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
+	"kubelearn/pkg/session"
+)
+
+// listQuestions lists the quiz questions without grading them, with the
+// namespaces mapped for the user.
+func listQuestions(w http.ResponseWriter, r *http.Request) {
+	questions := registry.List()
+	for i := range questions {
+		questions[i] = mapNamespaces(r, questions[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(questions)
+}
+
+// getQuestion describes one question without grading it, e.g.
+// GET /questions/4.
+func getQuestion(w http.ResponseWriter, r *http.Request) {
+	q, ok := pathQuestion(w, r)
+	if !ok {
+		return
+	}
+	info := mapNamespaces(r, registry.Describe(q))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// mapNamespaces maps the namespaces of info for the user of the request.
+func mapNamespaces(r *http.Request, info registry.Info) registry.Info {
+	info.Namespace = k8s.Namespace(r.Context(), info.Namespace)
+	info.CreatedNamespace = k8s.Namespace(r.Context(), info.CreatedNamespace)
+	return info
+}
+
+// checkQuestion grades one question, e.g. POST /questions/11/check. With
+// the session query parameter the check is recorded as an attempt of that
+// quiz session, and only counts while the session runs.
+func checkQuestion(clients *k8s.Clients, sessions *session.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q, ok := pathQuestion(w, r)
+		if !ok {
+			return
+		}
+
+		var attempt session.Attempt
+		if id := r.URL.Query().Get("session"); id != "" {
+			s, ok := ownedSession(w, r, sessions, id)
+			if !ok {
+				return
+			}
+			var err error
+			if attempt, err = sessions.Submit(r.Context(), s.ID, q.ID()); err != nil {
+				sessionError(w, err)
+				return
+			}
+		} else {
+			attempt = session.Attempt{Question: q.ID(), Time: time.Now(), Result: q.Check(r.Context(), clients)}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(attempt)
+	}
+}
+
+// pathQuestion returns the question given by the id path parameter, and
+// writes an error when there is none.
+func pathQuestion(w http.ResponseWriter, r *http.Request) (registry.Question, bool) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return nil, false
+	}
+	q, ok := registry.Get(id)
+	if !ok {
+		writeError(w, fmt.Sprintf("question %d not found", id), http.StatusNotFound)
+		return nil, false
+	}
+	return q, true
+}
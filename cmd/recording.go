@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"kubelearn/pkg/recording"
+	"kubelearn/pkg/terminal"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// replayIdleLimit caps the pauses of a replay unless the idle query
+// parameter says otherwise.
+const replayIdleLimit = 2 * time.Second
+
+// listRecordings lists the recorded terminal sessions, newest first. The
+// session query parameter selects the recordings of one quiz session.
+func listRecordings(recordings *recording.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := recordings.List(historyUser(r), r.URL.Query().Get("session"))
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+// downloadRecording sends the asciicast file of a recording, e.g.
+// GET /recordings/ID. It plays with asciinema play.
+func downloadRecording(recordings *recording.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec, ok := ownedRecording(w, r, recordings)
+		if !ok {
+			return
+		}
+		f, err := recordings.Open(rec.ID)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+
+		w.Header().Set("Content-Type", "application/x-asciicast")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", rec.ID+".cast"))
+		http.ServeContent(w, r, rec.ID+".cast", rec.StartedAt, f)
+	}
+}
+
+// replayRecording upgrades the request to a WebSocket and plays a
+// recording over it with the terminal protocol, so the same xterm.js view
+// can show it, e.g. /recordings/ID/replay?speed=2&idle=1. Output is sent
+// as binary frames and resizes as resize messages; the end of the
+// recording is sent as an exit message without a code. The client may
+// change the speed during the replay with {"type": "speed", "speed": 4}.
+func replayRecording(recordings *recording.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec, ok := ownedRecording(w, r, recordings)
+		if !ok {
+			return
+		}
+		speed := 1.0
+		if s := r.URL.Query().Get("speed"); s != "" {
+			var err error
+			if speed, err = strconv.ParseFloat(s, 64); err != nil {
+				writeError(w, "speed must be a number", http.StatusBadRequest)
+				return
+			}
+		}
+		idle := replayIdleLimit
+		if s := r.URL.Query().Get("idle"); s != "" {
+			seconds, err := strconv.ParseFloat(s, 64)
+			if err != nil || seconds < 0 {
+				writeError(w, "idle must be a number of seconds", http.StatusBadRequest)
+				return
+			}
+			idle = time.Duration(seconds * float64(time.Second))
+		}
+
+		f, err := recordings.Open(rec.ID)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		dec, err := recording.NewDecoder(f)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Println("Error upgrading to WebSocket:", err)
+			return
+		}
+		conn := terminal.NewConn(ws)
+		defer conn.Close()
+
+		player := recording.NewPlayer(dec, speed, idle)
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		go func() {
+			// Stop when the client goes away; apply its speed changes.
+			defer cancel()
+			for {
+				kind, data, err := ws.ReadMessage()
+				if err != nil {
+					return
+				}
+				var msg struct {
+					Type  string  `json:"type"`
+					Speed float64 `json:"speed"`
+				}
+				if kind != websocket.TextMessage || json.Unmarshal(data, &msg) != nil || msg.Type != "speed" {
+					conn.WriteMessage(terminal.Message{Type: terminal.TypeError, Error: "only speed messages are accepted during a replay"})
+					continue
+				}
+				player.SetSpeed(msg.Speed)
+			}
+		}()
+
+		conn.WriteMessage(terminal.Message{Type: terminal.TypeResize, Cols: uint16(dec.Header.Width), Rows: uint16(dec.Header.Height)})
+		err = player.Play(ctx, func(e recording.Event) error {
+			switch e.Code {
+			case recording.Output:
+				return conn.WriteOutput([]byte(e.Data))
+			case recording.Resize:
+				var cols, rows uint16
+				if _, err := fmt.Sscanf(e.Data, "%dx%d", &cols, &rows); err == nil {
+					return conn.WriteMessage(terminal.Message{Type: terminal.TypeResize, Cols: cols, Rows: rows})
+				}
+			}
+			// Input is echoed in the output, so it is not replayed.
+			return nil
+		})
+		if err != nil && !errors.Is(err, context.Canceled) {
+			conn.WriteMessage(terminal.Message{Type: terminal.TypeError, Error: err.Error()})
+			return
+		}
+		conn.WriteMessage(terminal.Message{Type: terminal.TypeExit})
+	}
+}
+
+// ownedRecording returns the recording given by the id path variable if
+// the user may see it, and writes an error otherwise.
+func ownedRecording(w http.ResponseWriter, r *http.Request, recordings *recording.Store) (recording.Recording, bool) {
+	rec, err := recordings.Get(mux.Vars(r)["id"])
+	if err == nil && !canAccess(r, rec.User) {
+		err = recording.ErrNotFound
+	}
+	if errors.Is(err, recording.ErrNotFound) {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return recording.Recording{}, false
+	}
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return recording.Recording{}, false
+	}
+	return rec, true
+}
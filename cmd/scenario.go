@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
+
+	"github.com/gorilla/mux"
+)
+
+// scenarioActions maps the scenario endpoints to what they do to a question.
+var scenarioActions = map[string]func(ctx context.Context, q registry.Question, clients *k8s.Clients) error{
+	"setup": func(ctx context.Context, q registry.Question, clients *k8s.Clients) error {
+		return q.Setup(ctx, clients)
+	},
+	"reset": registry.Reset,
+	"teardown": func(ctx context.Context, q registry.Question, clients *k8s.Clients) error {
+		return q.Cleanup(ctx, clients)
+	},
+}
+
+// scenarioResult is the body of a scenario action that completed.
+type scenarioResult struct {
+	// Question is missing when the action ran on every question.
+	Question int    `json:"question,omitempty"`
+	Action   string `json:"action"`
+	Status   string `json:"status"`
+}
+
+// handleScenario sets up, resets or tears down the scenario of the question
+// given by the id path variable, e.g. POST /questions/10/scenario/reset, or
+// of every question without it, e.g. POST /questions/scenario/reset.
+func handleScenario(clients *k8s.Clients) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		action := vars["action"]
+		run, ok := scenarioActions[action]
+		if !ok {
+			writeError(w, fmt.Sprintf("unknown scenario action %q", action), http.StatusNotFound)
+			return
+		}
+		if _, ok := vars["id"]; !ok {
+			for _, q := range registry.All() {
+				if err := run(r.Context(), q, clients); err != nil {
+					writeError(w, fmt.Sprintf("%s of question %d failed: %v", action, q.ID(), err), http.StatusInternalServerError)
+					return
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(scenarioResult{Action: action, Status: "done"})
+			return
+		}
+		// The route only matches digits.
+		id, _ := strconv.Atoi(vars["id"])
+		q, ok := registry.Get(id)
+		if !ok {
+			writeError(w, fmt.Sprintf("question %d not found", id), http.StatusNotFound)
+			return
+		}
+
+		if err := run(r.Context(), q, clients); err != nil {
+			writeError(w, fmt.Sprintf("%s of question %d failed: %v", action, id, err), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(scenarioResult{Question: id, Action: action, Status: "done"})
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	frontend "kubelearn/kubelearn-frontend"
+	"kubelearn/pkg/audit"
+	"kubelearn/pkg/auth"
+	"kubelearn/pkg/history"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/policy"
+	"kubelearn/pkg/provision"
+	"kubelearn/pkg/recording"
+	"kubelearn/pkg/sandbox"
+	"kubelearn/pkg/session"
+	"kubelearn/pkg/storage"
+
+	"github.com/spf13/cobra"
+)
+
+// apiPrefix is the path the API is served under.
+const apiPrefix = "/api/v1"
+
+// shutdownTimeout bounds how long serve waits for the requests in flight
+// when it is stopped.
+const shutdownTimeout = 10 * time.Second
+
+// serveOptions are the flags of the serve command.
+type serveOptions struct {
+	listen            string
+	scoringConfig     string
+	timeLimit         time.Duration
+	db                string
+	adminUser         string
+	isolateNamespaces bool
+	terminal          string
+	sandboxImage      string
+	shell             string
+	terminalPolicy    string
+	allowedOrigin     string
+	recordingsDir     string
+	frontendDir       string
+}
+
+func newServeCommand(kube *k8s.ConfigOptions) *cobra.Command {
+	var opts serveOptions
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the API and the terminals for the web frontend",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(cmd.Context(), *kube, opts)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&opts.listen, "listen", ":8083", "address the server listens on")
+	flags.StringVar(&opts.scoringConfig, "scoring-config", "", "path to a JSON file with difficulty weights and the pass threshold")
+	flags.DurationVar(&opts.timeLimit, "time-limit", session.DefaultTimeLimit, "time limit of a quiz session")
+	flags.StringVar(&opts.db, "db", "kubelearn.db", "path to the database file that keeps the user accounts and the score history")
+	flags.StringVar(&opts.adminUser, "admin-user", "admin", "username of the administrator created on first start")
+	flags.BoolVar(&opts.isolateNamespaces, "isolate-namespaces", false, "give every user their own copy of the exercise namespaces, prefixed with the username")
+	flags.StringVar(&opts.terminal, "terminal", "sandbox", "where /terminal runs shells: sandbox, in a pod inside the cluster, or local, on the backend host")
+	flags.StringVar(&opts.sandboxImage, "sandbox-image", sandbox.DefaultImage, "container image of the sandbox terminal pods")
+	flags.StringVar(&opts.shell, "shell", "", "shell started by /terminal in local mode; defaults to $SHELL or /bin/bash")
+	flags.StringVar(&opts.terminalPolicy, "terminal-policy", "", "path to a YAML or JSON file with the allow and deny rules for terminal commands")
+	flags.StringVar(&opts.allowedOrigin, "allowed-origin", "http://localhost:3000", "origin of the frontend allowed to call the API with credentials")
+	flags.StringVar(&opts.recordingsDir, "recordings-dir", "recordings", "directory that keeps the asciicast recordings of the terminals; empty disables recording")
+	flags.StringVar(&opts.frontendDir, "frontend-dir", "", "directory with a production build of the frontend to serve from / instead of the embedded one, e.g. kubelearn-frontend/build")
+	return cmd
+}
+
+// serve registers the API and serves it until ctx is cancelled or the
+// server fails.
+func serve(ctx context.Context, kube k8s.ConfigOptions, opts serveOptions) error {
+	// Learners administer their exercise namespaces from the sandbox, so
+	// each of them needs their own.
+	if opts.terminal == "sandbox" && !opts.isolateNamespaces {
+		return errors.New("--terminal sandbox requires --isolate-namespaces; use --terminal local for a single-user setup")
+	}
+	scoringConfig, err := loadScoring(opts.scoringConfig)
+	if err != nil {
+		return err
+	}
+
+	config, err := k8s.LoadKubeConfig(kube)
+	if err != nil {
+		return err
+	}
+	clients, err := k8s.NewClients(config)
+	if err != nil {
+		return fmt.Errorf("creating Kubernetes clients: %w", err)
+	}
+
+	db, err := storage.Open(opts.db)
+	if err != nil {
+		return fmt.Errorf("opening database: %w", err)
+	}
+	defer db.Close()
+
+	users, err := auth.New(db, auth.DefaultTokenTTL)
+	if err != nil {
+		return fmt.Errorf("opening user accounts: %w", err)
+	}
+	if err := bootstrapAdmin(users, opts.adminUser); err != nil {
+		return fmt.Errorf("creating administrator: %w", err)
+	}
+	store, err := history.New(db)
+	if err != nil {
+		return fmt.Errorf("opening score history: %w", err)
+	}
+
+	// Setup creates a kind cluster with Docker, which a pod cannot do. Once
+	// the cluster is ready, the clients are rebuilt from the kubeconfig
+	// that kind wrote.
+	var setupJob *provision.Job
+	if !k8s.InCluster(kube) {
+		setupJob = provision.NewJob(provision.Options{
+			KubeconfigPath: kube.Kubeconfig,
+			Reporter: func(event provision.Event) {
+				log.Printf("Setup %s: %s", event.Step, event.Message)
+				if event.Step == provision.StepReady {
+					reloadClients(clients, kube)
+				}
+			},
+		})
+	}
+
+	// Quiz sessions, timed and graded on the server
+	sessions := session.NewManager(clients, session.Options{
+		Scoring:   scoringConfig,
+		TimeLimit: opts.timeLimit,
+		Recorder: func(s session.Session) error {
+			err := store.Save(history.NewAttempt(s))
+			if err != nil {
+				log.Printf("Error saving session %s to the history: %v", s.ID, err)
+			}
+			return err
+		},
+		Archive: func(id string) (session.Session, bool) {
+			attempt, err := store.Get(id)
+			if err != nil {
+				return session.Session{}, false
+			}
+			return attempt.Session(), true
+		},
+	})
+
+	// Shells of the terminal WebSocket
+	var start startShell
+	switch opts.terminal {
+	case "sandbox":
+		start = sandboxShell(sandbox.New(clients, sandbox.Options{Image: opts.sandboxImage}))
+	case "local":
+		log.Println("Terminal shells run on the backend host; use --terminal sandbox on shared setups")
+		start = localShell(opts.shell)
+	default:
+		return fmt.Errorf("unknown terminal mode %q", opts.terminal)
+	}
+	var recordings *recording.Store
+	if opts.recordingsDir != "" {
+		recordings, err = recording.New(db, opts.recordingsDir)
+		if err != nil {
+			return fmt.Errorf("opening terminal recordings: %w", err)
+		}
+	}
+	commands, err := audit.New(db)
+	if err != nil {
+		return fmt.Errorf("opening command log: %w", err)
+	}
+	var terminalPolicy *policy.Policy
+	if opts.terminalPolicy != "" {
+		p, err := policy.Load(opts.terminalPolicy)
+		if err != nil {
+			return fmt.Errorf("loading terminal policy: %w", err)
+		}
+		terminalPolicy = &p
+	}
+
+	upgrader.CheckOrigin = checkOrigin(opts.allowedOrigin)
+
+	// Every API endpoint but the login and the OpenAPI document requires
+	// an authenticated user
+	api := newAPI(apiRoutes(apiDeps{
+		users:      users,
+		setupJob:   setupJob,
+		sessions:   sessions,
+		store:      store,
+		recordings: recordings,
+		commands:   commands,
+		start:      start,
+		policy:     terminalPolicy,
+		clients:    clients,
+	}), users, opts.isolateNamespaces)
+
+	// The API is served under /api/v1, with CORS for a frontend served
+	// elsewhere during development, and the frontend from everywhere else
+	mux := http.NewServeMux()
+	mux.Handle(apiPrefix+"/", http.StripPrefix(apiPrefix, withCORS(opts.allowedOrigin, api)))
+	build, err := frontendBuild(opts.frontendDir)
+	if err != nil {
+		return err
+	}
+	if build != nil {
+		mux.Handle("/", serveFrontend(build))
+	} else {
+		log.Println("The frontend is not built into this binary; run make frontend or use --frontend-dir")
+	}
+	srv := &http.Server{Addr: opts.listen, Handler: mux}
+	failed := make(chan error, 1)
+	go func() {
+		failed <- srv.ListenAndServe()
+	}()
+	log.Printf("Serving on %s", opts.listen)
+	select {
+	case err := <-failed:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Finish the requests in flight, but not the terminals, which the
+	// server no longer tracks once upgraded to WebSockets
+	log.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// reloadClients points clients at the cluster the kubeconfig selects now.
+func reloadClients(clients *k8s.Clients, kube k8s.ConfigOptions) {
+	config, err := k8s.LoadKubeConfig(kube)
+	if err == nil {
+		err = clients.Reload(config)
+	}
+	if err != nil {
+		log.Printf("Error reloading the Kubernetes clients after setup: %v", err)
+	}
+}
+
+// frontendBuild returns the production build of the frontend in dir, or
+// the one embedded in the binary when dir is empty. It returns nil when
+// the binary was built without it.
+func frontendBuild(dir string) (fs.FS, error) {
+	if dir == "" {
+		build, _ := frontend.Build()
+		return build, nil
+	}
+	build := os.DirFS(dir)
+	if _, err := fs.Stat(build, "index.html"); err != nil {
+		return nil, fmt.Errorf("serving the frontend: %w", err)
+	}
+	return build, nil
+}
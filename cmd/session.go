@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"kubelearn/pkg/auth"
+	"kubelearn/pkg/output"
+	"kubelearn/pkg/scoring"
+	"kubelearn/pkg/session"
+	"kubelearn/pkg/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// startRequest is the body of POST /sessions.
+type startRequest struct {
+	Questions []int `json:"questions,omitempty"`
+}
+
+// quizResults is the JSON body of a finished session: its score, the
+// results of its questions and the session itself.
+type quizResults struct {
+	scoring.Report
+	Results []utils.Result  `json:"results"`
+	Session session.Session `json:"session"`
+}
+
+// startQuiz starts a timed quiz session. The body may select the questions,
+// e.g. {"questions": [1, 4, 11]}; by default every question is selected.
+// The time limit is set by the server.
+func startQuiz(sessions *session.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req startRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			writeError(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		user, _ := auth.FromContext(r.Context())
+		s, err := sessions.Start(r.Context(), user.Username, req.Questions)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(s)
+	}
+}
+
+// getSession returns the state, remaining time and attempts of a session,
+// e.g. GET /sessions/ID.
+func getSession(sessions *session.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s, ok := ownedSession(w, r, sessions, mux.Vars(r)["id"])
+		if !ok {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s)
+	}
+}
+
+// finishQuiz grades the selected questions of a session and scores them.
+// Sessions whose time ran out were already graded at the deadline, and
+// return those frozen results, e.g. POST /sessions/ID/finish.
+func finishQuiz(sessions *session.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, ok := resultFormat(r)
+		if !ok {
+			writeError(w, "no acceptable output format; use one of "+strings.Join(output.Names(), ", "), http.StatusNotAcceptable)
+			return
+		}
+		formatter, err := output.Get(format, false)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s, ok := ownedSession(w, r, sessions, mux.Vars(r)["id"])
+		if !ok {
+			return
+		}
+		s, err = sessions.Finish(s.ID)
+		if err != nil {
+			sessionError(w, err)
+			return
+		}
+
+		if format != "json" {
+			// Format into a buffer, so a failure can still be reported
+			var buf bytes.Buffer
+			if err := formatter.Format(&buf, s.Results, s.Report); err != nil {
+				log.Printf("Error formatting the results of session %s as %s: %v", s.ID, format, err)
+				writeError(w, fmt.Sprintf("formatting the results as %s: %v", format, err), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", formatter.ContentType())
+			buf.WriteTo(w)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(quizResults{*s.Report, s.Results, s})
+	}
+}
+
+// resultFormat returns the output format of graded results requested with
+// the output query parameter, or else negotiated from the Accept header.
+func resultFormat(r *http.Request) (string, bool) {
+	if format := r.URL.Query().Get("output"); format != "" {
+		return format, true
+	}
+	return output.Negotiate(r.Header.Get("Accept"))
+}
+
+// ownedSession looks up the session with the given ID. Sessions of other
+// users are reported as not found.
+func ownedSession(w http.ResponseWriter, r *http.Request, sessions *session.Manager, id string) (session.Session, bool) {
+	s, err := sessions.Get(id)
+	if err == nil && !canAccess(r, s.User) {
+		err = session.ErrNotFound
+	}
+	if err != nil {
+		sessionError(w, err)
+		return session.Session{}, false
+	}
+	return s, true
+}
+
+// sessionError maps session errors to HTTP status codes.
+func sessionError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, session.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, session.ErrNotSelected):
+		status = http.StatusBadRequest
+	case errors.Is(err, session.ErrExpired), errors.Is(err, session.ErrFinished):
+		status = http.StatusConflict
+	}
+	writeError(w, err.Error(), status)
+}
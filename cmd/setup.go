@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"kubelearn/pkg/provision"
+)
+
+// setupEnvironment starts provisioning the kind cluster and the question
+// scenarios in the background. A call while a run is in progress does not
+// start another one; both cases answer with the job status.
+func setupEnvironment(job *provision.Job) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusOK
+		if job.Start() {
+			status = http.StatusAccepted
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(job.Status())
+	}
+}
+
+// setupStatus returns the state and logs of the setup job.
+func setupStatus(job *provision.Job) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(job.Status())
+	}
+}
+
+// setupEvents streams the setup progress as Server-Sent Events. Events
+// logged before the client connected are replayed first, and the stream
+// ends with a done event carrying the final status.
+func setupEvents(job *provision.Job) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+
+		past, events, cancel := job.Subscribe()
+		defer cancel()
+
+		for _, event := range past {
+			writeServerSentEvent(w, "progress", event)
+		}
+		flusher.Flush()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					writeServerSentEvent(w, "done", job.Status())
+					flusher.Flush()
+					return
+				}
+				writeServerSentEvent(w, "progress", event)
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+func writeServerSentEvent(w http.ResponseWriter, name string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Error encoding %s event: %v", name, err)
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"kubelearn/pkg/audit"
+	"kubelearn/pkg/auth"
+	"kubelearn/pkg/policy"
+	"kubelearn/pkg/recording"
+	"kubelearn/pkg/sandbox"
+	"kubelearn/pkg/session"
+	"kubelearn/pkg/terminal"
+)
+
+// startShell starts the shell of a terminal request with env added to its
+// environment and the given size.
+type startShell func(r *http.Request, env []string, cols, rows uint16) (terminal.Process, error)
+
+// localShell runs shells on the backend host. It is only meant for
+// single-user setups, since the shell has the rights of the backend.
+func localShell(shell string) startShell {
+	return func(r *http.Request, env []string, cols, rows uint16) (terminal.Process, error) {
+		return terminal.StartLocal(shell, env, cols, rows)
+	}
+}
+
+// sandboxShell runs shells in the terminal pod of the user inside the
+// cluster.
+func sandboxShell(sandboxes *sandbox.Manager) startShell {
+	return func(r *http.Request, env []string, cols, rows uint16) (terminal.Process, error) {
+		user, _ := auth.FromContext(r.Context())
+		return sandboxes.Start(r.Context(), user.Username, env, cols, rows)
+	}
+}
+
+// handleTerminal upgrades the request to a WebSocket and attaches it to a
+// shell with a terminal. The initial size may be given with the cols and
+// rows query parameters, e.g. /terminal?cols=120&rows=40. Command lines
+// the policy denies are not run, and the client gets a policy message. The
+// commands run in the terminal are logged in commands and, unless
+// recordings is nil, the terminal is recorded; both are linked to the quiz
+// session given by the session query parameter.
+func handleTerminal(start startShell, pol *policy.Policy, sessions *session.Manager, recordings *recording.Store, commands *audit.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cols := querySize(r, "cols", 80)
+		rows := querySize(r, "rows", 24)
+
+		var sessionID string
+		if id := r.URL.Query().Get("session"); id != "" {
+			s, ok := ownedSession(w, r, sessions, id)
+			if !ok {
+				return
+			}
+			sessionID = s.ID
+		}
+
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Println("Error upgrading to WebSocket:", err)
+			return
+		}
+		conn := terminal.NewConn(ws)
+
+		var p terminal.Process
+		nonce, err := terminal.NewNonce()
+		if err == nil {
+			p, err = start(r, terminal.ShellEnv(nonce), cols, rows)
+		}
+		if err != nil {
+			conn.WriteMessage(terminal.Message{Type: terminal.TypeError, Error: err.Error()})
+			conn.Close()
+			return
+		}
+		user, _ := auth.FromContext(r.Context())
+		var rec *recording.Recorder
+		if recordings != nil {
+			rec, err = recordings.Create(user.Username, sessionID, cols, rows)
+			if err != nil {
+				log.Printf("Error recording terminal of %s: %v", user.Username, err)
+			}
+		}
+		hooks := terminal.Hooks{
+			Finished: func(line string, exitCode int) {
+				c := audit.NewCommand(line, exitCode)
+				c.User, c.Session = user.Username, sessionID
+				if rec != nil {
+					c.Recording = rec.Recording().ID
+				}
+				if _, err := commands.Add(c); err != nil {
+					log.Printf("Error logging command of %s: %v", user.Username, err)
+				}
+			},
+		}
+		if pol != nil {
+			hooks.Check = func(line string) error {
+				err := pol.Check(line)
+				var v *policy.Violation
+				if errors.As(err, &v) {
+					log.Printf("Blocked command of %s: %s", user.Username, v.Line)
+					conn.WriteMessage(terminal.Message{Type: terminal.TypePolicy, Error: v.Message, Command: v.Line, Rule: v.Rule})
+				}
+				return err
+			}
+		}
+		p = terminal.Integrate(p, nonce, hooks)
+		if rec != nil {
+			p = recording.Wrap(p, rec)
+		}
+		terminal.Serve(conn, p)
+	}
+}
+
+func querySize(r *http.Request, name string, fallback uint16) uint16 {
+	n, err := strconv.ParseUint(r.URL.Query().Get(name), 10, 16)
+	if err != nil || n == 0 {
+		return fallback
+	}
+	return uint16(n)
+}
@@ -0,0 +1,27 @@
+// Package frontend embeds the production build of the React app, so the
+// backend serves it without a Node runtime.
+package frontend
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// dist holds the build copied by make frontend. Only its README is kept in
+// the repository, so the backend builds without Node.js.
+//
+//go:embed all:dist
+var dist embed.FS
+
+// Build returns the files of the production build, or false when the
+// binary was built without it.
+func Build() (fs.FS, bool) {
+	build, err := fs.Sub(dist, "dist")
+	if err != nil {
+		return nil, false
+	}
+	if _, err := fs.Stat(build, "index.html"); err != nil {
+		return nil, false
+	}
+	return build, true
+}
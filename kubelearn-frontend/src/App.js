@@ -1,23 +1,108 @@
-import React, { useState, useEffect } from 'react';
+import React, { useState, useEffect, useCallback } from 'react';
+
+// The backend serves the app and its API on the same origin. During
+// development, npm start proxies the API to the backend.
+const API_URL = '/api/v1';
 
 
 function App() {
   const [questions, setQuestions] = useState([]);
+  const [results, setResults] = useState([]);
   const [quizStarted, setQuizStarted] = useState(false);
   const [quizFinished, setQuizFinished] = useState(false);
   const [score, setScore] = useState(0);
-  const [elapsedTime, setElapsedTime] = useState(0);
+  const [report, setReport] = useState(null);
+  const [sessionId, setSessionId] = useState(null);
+  const [deadline, setDeadline] = useState(null);
+  const [remainingTime, setRemainingTime] = useState(0);
+  const [setupStatus, setSetupStatus] = useState(null);
+  const [user, setUser] = useState(null);
+  const [username, setUsername] = useState('');
+  const [password, setPassword] = useState('');
+  const [loginError, setLoginError] = useState('');
 
-  const startQuiz = () => {
-    setQuizStarted(true);
-    setQuizFinished(false);
-    setElapsedTime(0);
-    fetchQuestions();
+  useEffect(() => {
+    fetch(`${API_URL}/me`, { credentials: 'include' })
+      .then(response => (response.ok ? response.json() : null))
+      .then(setUser)
+      .catch(() => setUser(null));
+  }, []);
+
+  const login = async (event) => {
+    event.preventDefault();
+    try {
+      const response = await fetch(`${API_URL}/login`, {
+        method: 'POST',
+        credentials: 'include',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ username, password }),
+      });
+      if (!response.ok) {
+        setLoginError('Invalid username or password');
+        return;
+      }
+      const data = await response.json();
+      setUser(data.user);
+      setPassword('');
+      setLoginError('');
+    } catch (error) {
+      console.error('Error logging in:', error);
+    }
+  };
+
+  const logout = async () => {
+    await fetch(`${API_URL}/logout`, { method: 'POST', credentials: 'include' });
+    setUser(null);
+    resetQuiz();
+  };
+
+  const setupEnvironment = async () => {
+    try {
+      const response = await fetch(`${API_URL}/setup`, { method: 'POST', credentials: 'include' });
+      setSetupStatus(await response.json());
+    } catch (error) {
+      console.error('Error starting setup:', error);
+      return;
+    }
+
+    const events = new EventSource(`${API_URL}/setup/events`, { withCredentials: true });
+    events.addEventListener('progress', (e) => {
+      const event = JSON.parse(e.data);
+      setSetupStatus(prev => ({
+        ...prev,
+        state: event.step,
+        logs: [...((prev && prev.logs) || []), event],
+      }));
+    });
+    events.addEventListener('done', (e) => {
+      setSetupStatus(JSON.parse(e.data));
+      events.close();
+    });
+    events.onerror = () => events.close();
+  };
+
+  const startQuiz = async () => {
+    try {
+      const response = await fetch(`${API_URL}/sessions`, { method: 'POST', credentials: 'include' });
+      if (!response.ok) {
+        throw new Error('Network response was not ok');
+      }
+      const session = await response.json();
+      setSessionId(session.id);
+      // The server owns the deadline; only the countdown runs in the browser.
+      setDeadline(Date.now() + session.remainingSeconds * 1000);
+      setRemainingTime(Math.round(session.remainingSeconds));
+      setQuizStarted(true);
+      setQuizFinished(false);
+      fetchQuestions();
+    } catch (error) {
+      console.error('Error starting quiz:', error);
+    }
   };
 
   const fetchQuestions = async () => {
     try {
-      const response = await fetch('http://localhost:8083/questions');
+      const response = await fetch(`${API_URL}/questions`, { credentials: 'include' });
       if (!response.ok) {
         throw new Error('Network response was not ok');
       }
@@ -28,34 +113,45 @@ function App() {
     }
   };
 
-  const finishQuiz = async () => {
+  const finishQuiz = useCallback(async () => {
     try {
-      const response = await fetch('http://localhost:8083/finish');
+      const response = await fetch(`${API_URL}/sessions/${sessionId}/finish`, { method: 'POST', credentials: 'include' });
       const data = await response.json();
       setScore(Math.round(data.score));
+      setReport(data);
+      setResults(data.results);
       setQuizFinished(true);
     } catch (error) {
       console.error('Error finishing quiz:', error);
     }
-  };
+  }, [sessionId]);
 
   const resetQuiz = () => {
     setQuizStarted(false);
     setQuizFinished(false);
     setQuestions([]);
+    setResults([]);
     setScore(0);
-    setElapsedTime(0);
+    setReport(null);
+    setSessionId(null);
+    setDeadline(null);
+    setRemainingTime(0);
   };
 
   useEffect(() => {
     let timer;
-    if (quizStarted && !quizFinished) {
+    if (quizStarted && !quizFinished && deadline) {
       timer = setInterval(() => {
-        setElapsedTime(prevTime => prevTime + 1);
+        const remaining = Math.max(0, Math.round((deadline - Date.now()) / 1000));
+        setRemainingTime(remaining);
+        if (remaining === 0) {
+          clearInterval(timer);
+          finishQuiz();
+        }
       }, 1000);
     }
     return () => clearInterval(timer);
-  }, [quizStarted, quizFinished]);
+  }, [quizStarted, quizFinished, deadline, finishQuiz]);
 
   const getDifficultyColor = (difficulty) => {
     let colorClass = '';
@@ -75,22 +171,78 @@ function App() {
       />
         <h1 className="text-3xl font-bold text-gray-800 mb-20">“Kubernetes feels like magic… until it breaks. Let’s learn to tame the YAML together. Test your Kubernetes superpowers</h1>
       <div className="flex flex-col items-center w-full max-w-7xl">
-        {!quizStarted && (
-          <button
-            onClick={startQuiz}
-            className="bg-green-500 hover:bg-green-700 text-white font-bold py-2 px-4 rounded"
-          >
-            Start Quiz
-          </button>
+        {!user && (
+          <form onSubmit={login} className="flex flex-col items-center gap-2">
+            <input
+              value={username}
+              onChange={(e) => setUsername(e.target.value)}
+              placeholder="Username"
+              className="border rounded py-2 px-4"
+            />
+            <input
+              type="password"
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+              placeholder="Password"
+              className="border rounded py-2 px-4"
+            />
+            <button type="submit" className="bg-green-500 hover:bg-green-700 text-white font-bold py-2 px-4 rounded">
+              Log In
+            </button>
+            {loginError && <div className="text-red-500">{loginError}</div>}
+          </form>
+        )}
+
+        {user && (
+          <div className="self-end text-gray-800 mb-4">
+            {user.username}{' '}
+            <button onClick={logout} className="underline">Log Out</button>
+          </div>
+        )}
+
+        {user && !quizStarted && (
+          <div className="flex flex-col items-center">
+            <div className="flex gap-4">
+              {user.admin && (
+                <button
+                  onClick={setupEnvironment}
+                  disabled={setupStatus && setupStatus.running}
+                  className="bg-blue-500 hover:bg-blue-700 disabled:opacity-50 text-white font-bold py-2 px-4 rounded"
+                >
+                  Set Up Environment
+                </button>
+              )}
+              <button
+                onClick={startQuiz}
+                className="bg-green-500 hover:bg-green-700 text-white font-bold py-2 px-4 rounded"
+              >
+                Start Quiz
+              </button>
+            </div>
+            {setupStatus && (
+              <div className="mt-4 text-gray-800 text-left">
+                <div className="font-bold">Setup: {setupStatus.state}</div>
+                {setupStatus.error && <div className="text-red-500">{setupStatus.error}</div>}
+                {setupStatus.logs && setupStatus.logs.length > 0 && (
+                  <div className="text-sm">{setupStatus.logs[setupStatus.logs.length - 1].message}</div>
+                )}
+              </div>
+            )}
+          </div>
         )}
 
         {quizStarted && !quizFinished && (
           <div className="w-full">
             <div className="w-full flex flex-col">
               <div className="text-lg font-bold text-gray-800 mb-4">
-                Time Elapsed: {Math.floor(elapsedTime / 60)}:
-                {elapsedTime % 60 < 10 ? `0${elapsedTime % 60}` : elapsedTime % 60}
+                Time Remaining: {Math.floor(remainingTime / 60)}:
+                {remainingTime % 60 < 10 ? `0${remainingTime % 60}` : remainingTime % 60}
               </div>
+              {user.namespacePrefix && (
+                <div className="text-gray-800 mb-4">
+                  Your exercise namespaces are prefixed with <code>{user.namespacePrefix}-</code>, e.g. <code>{user.namespacePrefix}-colors</code> for namespace colors.
+                </div>
+              )}
 
               <div className="flex-1">
                 <table className="min-w-full bg-white shadow-md rounded-lg overflow-hidden h-full">
@@ -101,11 +253,18 @@ function App() {
                     </tr>
                   </thead>
                   <tbody className="text-gray-600 text-sm font-light">
-                    {questions.map((question, index) => (
-                      <tr key={index} className="border-b border-gray-200 hover:bg-gray-100">
-                        <td className="py-3 px-6 text-left font-bold">{question.TestName}</td>
-                        <td className={`py-3 px-6 text-left ${getDifficultyColor(question.Difficulty)}`}>
-                          {question.Difficulty}
+                    {questions.map((question) => (
+                      <tr key={question.id} className="border-b border-gray-200 hover:bg-gray-100">
+                        <td className="py-3 px-6 text-left font-bold">
+                          {question.title}
+                          {user.namespacePrefix && (question.namespace || question.createdNamespace) && (
+                            <div className="font-normal">
+                              Namespace: <code>{question.namespace || question.createdNamespace}</code>
+                            </div>
+                          )}
+                        </td>
+                        <td className={`py-3 px-6 text-left ${getDifficultyColor(question.difficulty)}`}>
+                          {question.difficulty}
                         </td>
                       </tr>
                     ))}
@@ -126,7 +285,10 @@ function App() {
           <div className="text-center mt-6 w-full">
             <h2 className="text-2xl font-semibold text-gray-800">Results</h2>
             <p className="text-gray-700">Score: {score}%</p>
-            {score < 85 ? (
+            {report && (
+              <p className="text-gray-700">Pass mark: {report.passThreshold}%</p>
+            )}
+            {!report || !report.passed ? (
               <p className="text-red-600 font-bold mt-4">
                 **You did not reach the minimum score to pass. Keep studying!**
               </p>
@@ -135,23 +297,55 @@ function App() {
                 **Congratulations! You passed! Keep studying!**
               </p>
             )}
+            {report && (
+              <div className="flex flex-col md:flex-row gap-4 mt-4">
+                {[['Difficulty', report.byDifficulty], ['Topic', report.byTopic]].map(([label, breakdown]) => (
+                  <table key={label} className="flex-1 bg-white shadow-md rounded-lg overflow-hidden">
+                    <thead className="bg-gray-200 text-gray-600 uppercase text-sm leading-normal">
+                      <tr>
+                        <th className="py-3 px-6 text-left">{label}</th>
+                        <th className="py-3 px-6 text-left">Score</th>
+                      </tr>
+                    </thead>
+                    <tbody className="text-gray-600 text-sm font-light">
+                      {Object.entries(breakdown || {}).map(([name, part]) => (
+                        <tr key={name} className="border-b border-gray-200">
+                          <td className="py-3 px-6 text-left font-bold">{name}</td>
+                          <td className="py-3 px-6 text-left">{Math.round(part.score)}%</td>
+                        </tr>
+                      ))}
+                    </tbody>
+                  </table>
+                ))}
+              </div>
+            )}
             <table className="min-w-full bg-white shadow-md rounded-lg overflow-hidden mt-4">
               <thead className="bg-gray-200 text-gray-600 uppercase text-sm leading-normal">
                 <tr>
                   <th className="py-3 px-6 text-left">Question</th>
                   <th className="py-3 px-6 text-left">Difficulty</th>
                   <th className="py-3 px-6 text-left">Result</th>
+                  <th className="py-3 px-6 text-left">Details</th>
                 </tr>
               </thead>
               <tbody className="text-gray-600 text-sm font-light">
-                {questions.map((question, index) => (
-                  <tr key={index} className="border-b border-gray-200 hover:bg-gray-100">
-                    <td className="py-3 px-6 text-left font-bold">{question.TestName}</td>
-                    <td className={`py-3 px-6 text-left ${getDifficultyColor(question.Difficulty)}`}>
-                      {question.Difficulty}
+                {results.map((result) => (
+                  <tr key={result.id} className="border-b border-gray-200 hover:bg-gray-100">
+                    <td className="py-3 px-6 text-left font-bold">{result.testName}</td>
+                    <td className={`py-3 px-6 text-left ${getDifficultyColor(result.difficulty)}`}>
+                      {result.difficulty}
+                    </td>
+                    <td className="py-3 px-6 text-left">
+                      {result.passed ? '✅' : '❌'}
                     </td>
                     <td className="py-3 px-6 text-left">
-                      {question.Passed ? '✅' : '❌'}
+                      <ul>
+                        {(result.criteria || []).filter((criterion) => !criterion.passed).map((criterion) => (
+                          <li key={criterion.name}>
+                            <span className="font-bold">{criterion.name}</span>: expected {criterion.expected}, got {criterion.observed}
+                          </li>
+                        ))}
+                      </ul>
                     </td>
                   </tr>
                 ))}
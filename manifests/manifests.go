@@ -0,0 +1,13 @@
+// Package manifests embeds the scenario manifests that questions start from,
+// so they can be applied without a checkout of the repository.
+package manifests
+
+import "embed"
+
+//go:embed *.yaml
+var FS embed.FS
+
+// Read returns the content of the named manifest.
+func Read(name string) ([]byte, error) {
+	return FS.ReadFile(name)
+}
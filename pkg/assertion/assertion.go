@@ -0,0 +1,145 @@
+package assertion
+
+import (
+	"fmt"
+	"sync"
+
+	"kubelearn/pkg/utils"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Engine evaluates CEL expressions against Kubernetes objects, which are
+// exposed as the variable object in the style of ValidatingAdmissionPolicy:
+//
+//	object.rules.exists(r, 'pods' in r.resources && ['get', 'list', 'watch'].all(v, v in r.verbs))
+type Engine struct {
+	env *cel.Env
+
+	mu       sync.Mutex
+	programs map[string]cel.Program
+}
+
+// NewEngine returns an engine with the standard CEL library and the string
+// extensions.
+func NewEngine() (*Engine, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		ext.Strings(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Engine{env: env, programs: map[string]cel.Program{}}, nil
+}
+
+// Compile parses and type-checks a boolean expression. Compiled programs are
+// cached, so it is cheap to call repeatedly with the same expression.
+func (e *Engine) Compile(expr string) (cel.Program, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if prg, ok := e.programs[expr]; ok {
+		return prg, nil
+	}
+	ast, issues := e.env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("expression must return a bool, not %s", ast.OutputType())
+	}
+	prg, err := e.env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	e.programs[expr] = prg
+	return prg, nil
+}
+
+// Eval evaluates expr with object bound to obj. obj may be a typed
+// Kubernetes object or its unstructured map.
+func (e *Engine) Eval(expr string, obj interface{}) (bool, error) {
+	prg, err := e.Compile(expr)
+	if err != nil {
+		return false, err
+	}
+	object, err := toUnstructured(obj)
+	if err != nil {
+		return false, err
+	}
+	out, _, err := prg.Eval(map[string]interface{}{"object": object})
+	if err != nil {
+		return false, err
+	}
+	passed, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression returned %v instead of a bool", out.Value())
+	}
+	return passed, nil
+}
+
+// Criterion evaluates expr against obj and reports it as a graded criterion.
+func (e *Engine) Criterion(name, expr string, obj interface{}) utils.Criterion {
+	passed, err := e.Eval(expr, obj)
+	observed := fmt.Sprint(passed)
+	if err != nil {
+		observed = err.Error()
+	}
+	return utils.Criterion{Name: name, Expected: expr, Observed: observed, Passed: passed}
+}
+
+func toUnstructured(obj interface{}) (map[string]interface{}, error) {
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		return o, nil
+	case runtime.Unstructured:
+		return o.UnstructuredContent(), nil
+	default:
+		return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	}
+}
+
+var (
+	defaultEngine     *Engine
+	defaultEngineErr  error
+	defaultEngineOnce sync.Once
+)
+
+func engine() (*Engine, error) {
+	defaultEngineOnce.Do(func() {
+		defaultEngine, defaultEngineErr = NewEngine()
+	})
+	return defaultEngine, defaultEngineErr
+}
+
+// Eval evaluates expr against obj with the shared engine.
+func Eval(expr string, obj interface{}) (bool, error) {
+	e, err := engine()
+	if err != nil {
+		return false, err
+	}
+	return e.Eval(expr, obj)
+}
+
+// Compile checks that expr is a valid boolean expression for the shared engine.
+func Compile(expr string) error {
+	e, err := engine()
+	if err != nil {
+		return err
+	}
+	_, err = e.Compile(expr)
+	return err
+}
+
+// Criterion evaluates expr against obj with the shared engine and reports it
+// as a graded criterion.
+func Criterion(name, expr string, obj interface{}) utils.Criterion {
+	e, err := engine()
+	if err != nil {
+		return utils.Criterion{Name: name, Expected: expr, Observed: err.Error()}
+	}
+	return e.Criterion(name, expr, obj)
+}
@@ -0,0 +1,197 @@
+// Package audit logs the commands learners run in their terminals, parsed
+// with kubectl in mind, and measures how efficiently they solve questions.
+package audit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"kubelearn/pkg/command"
+	"kubelearn/pkg/session"
+	"kubelearn/pkg/storage"
+	"kubelearn/pkg/utils"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var commandsBucket = []byte("commands")
+
+// Command is a command line run in a terminal.
+type Command struct {
+	ID   string `json:"id"`
+	User string `json:"user"`
+	// Session is the quiz session the terminal was used in, if any.
+	Session string `json:"session,omitempty"`
+	// Recording is the recording of the terminal, if any.
+	Recording string    `json:"recording,omitempty"`
+	Time      time.Time `json:"time"`
+	Line      string    `json:"line"`
+	ExitCode  int       `json:"exitCode"`
+	// Invocations are the simple commands of the line, e.g. both sides of
+	// a pipe.
+	Invocations []command.Invocation `json:"invocations,omitempty"`
+}
+
+// NewCommand parses a command line that finished with exitCode.
+func NewCommand(line string, exitCode int) Command {
+	return Command{
+		Time:        time.Now(),
+		Line:        line,
+		ExitCode:    exitCode,
+		Invocations: command.Parse(line),
+	}
+}
+
+// Kubectl reports whether the command line runs kubectl.
+func (c Command) Kubectl() bool {
+	for _, inv := range c.Invocations {
+		if inv.Kubectl != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Store keeps the command log in a bbolt database.
+type Store struct {
+	db *bolt.DB
+}
+
+// New returns a store that keeps the command log in db.
+func New(db *bolt.DB) (*Store, error) {
+	if err := storage.CreateBuckets(db, commandsBucket); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Add logs a command and assigns its ID.
+func (s *Store) Add(c Command) (Command, error) {
+	id, err := newID()
+	if err != nil {
+		return c, err
+	}
+	c.ID = id
+	data, err := json.Marshal(c)
+	if err != nil {
+		return c, err
+	}
+	return c, s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(commandsBucket).Put([]byte(c.ID), data)
+	})
+}
+
+// List returns the commands of user, or of every user when user is empty,
+// oldest first. When session is not empty only the commands run during
+// that quiz session are returned.
+func (s *Store) List(user, session string) ([]Command, error) {
+	commands := []Command{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(commandsBucket).ForEach(func(_, data []byte) error {
+			var c Command
+			if err := json.Unmarshal(data, &c); err != nil {
+				return err
+			}
+			if (user == "" || c.User == user) && (session == "" || c.Session == session) {
+				commands = append(commands, c)
+			}
+			return nil
+		})
+	})
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Time.Before(commands[j].Time) })
+	return commands, err
+}
+
+// QuestionCommands are the commands run for one question.
+type QuestionCommands struct {
+	Question int       `json:"question"`
+	Solved   bool      `json:"solved"`
+	Commands []Command `json:"commands"`
+}
+
+// Summary measures how a learner worked through a quiz session.
+type Summary struct {
+	Session  string `json:"session"`
+	Commands int    `json:"commands"`
+	Kubectl  int    `json:"kubectlCommands"`
+	Failed   int    `json:"failedCommands"`
+	Solved   int    `json:"solvedQuestions"`
+	// CommandsPerSolved is the number of commands per solved question. It
+	// is omitted while no question is solved.
+	CommandsPerSolved *float64 `json:"commandsPerSolved,omitempty"`
+	// Questions attributes each command to the first question checked
+	// after it ran, ordered by question ID.
+	Questions []QuestionCommands `json:"questions"`
+	// Unchecked are the commands run after the last check.
+	Unchecked []Command `json:"unchecked"`
+}
+
+// Summarize measures the commands of a session given its checks and, once
+// it is graded, its results. A question counts as solved when its result
+// passed, or before grading when one of its checks passed.
+func Summarize(sessionID string, commands []Command, checks []session.Attempt, results []utils.Result) Summary {
+	sum := Summary{Session: sessionID, Questions: []QuestionCommands{}, Unchecked: []Command{}}
+
+	byQuestion := map[int]*QuestionCommands{}
+	question := func(id int) *QuestionCommands {
+		q := byQuestion[id]
+		if q == nil {
+			q = &QuestionCommands{Question: id, Commands: []Command{}}
+			byQuestion[id] = q
+		}
+		return q
+	}
+	if len(results) > 0 {
+		for _, r := range results {
+			question(r.ID).Solved = r.Passed
+		}
+	} else {
+		for _, c := range checks {
+			q := question(c.Question)
+			q.Solved = q.Solved || c.Result.Passed
+		}
+	}
+
+	checks = append([]session.Attempt(nil), checks...)
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Time.Before(checks[j].Time) })
+	for _, c := range commands {
+		sum.Commands++
+		if c.Kubectl() {
+			sum.Kubectl++
+		}
+		if c.ExitCode != 0 {
+			sum.Failed++
+		}
+		i := sort.Search(len(checks), func(i int) bool { return !checks[i].Time.Before(c.Time) })
+		if i == len(checks) {
+			sum.Unchecked = append(sum.Unchecked, c)
+			continue
+		}
+		q := question(checks[i].Question)
+		q.Commands = append(q.Commands, c)
+	}
+
+	for _, q := range byQuestion {
+		if q.Solved {
+			sum.Solved++
+		}
+		sum.Questions = append(sum.Questions, *q)
+	}
+	sort.Slice(sum.Questions, func(i, j int) bool { return sum.Questions[i].Question < sum.Questions[j].Question })
+	if sum.Solved > 0 {
+		perSolved := float64(sum.Commands) / float64(sum.Solved)
+		sum.CommandsPerSolved = &perSolved
+	}
+	return sum
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
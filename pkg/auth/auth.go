@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"kubelearn/pkg/storage"
+
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultTokenTTL is how long a login stays valid.
+const DefaultTokenTTL = 24 * time.Hour
+
+// minPasswordLength is the shortest password accepted for an account.
+const minPasswordLength = 8
+
+var (
+	usersBucket  = []byte("users")
+	tokensBucket = []byte("tokens")
+)
+
+// usernamePattern keeps usernames valid as part of a Kubernetes namespace
+// name.
+var usernamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,30}[a-z0-9])?$`)
+
+// reservedUsernames would prefix exercise namespaces into namespaces of
+// Kubernetes or of kubelearn itself, e.g. kube with public to kube-public.
+var reservedUsernames = map[string]bool{"kube": true, "kubelearn": true, "default": true}
+
+// reservedUsername reports whether username may not be registered.
+func reservedUsername(username string) bool {
+	return reservedUsernames[username] || strings.HasPrefix(username, "kube-")
+}
+
+var (
+	ErrUserExists         = errors.New("user already exists")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUnauthenticated    = errors.New("authentication required")
+)
+
+// User is a learner or instructor account.
+type User struct {
+	Username  string    `json:"username"`
+	Admin     bool      `json:"admin"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// storedUser is how a user is kept in the database; the password hash is
+// left out of the API representation of User.
+type storedUser struct {
+	User
+	PasswordHash []byte `json:"passwordHash"`
+}
+
+// token is a login, kept under the SHA-256 hash of its secret.
+type token struct {
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Store keeps the user accounts and their login tokens in a bbolt database.
+type Store struct {
+	db  *bolt.DB
+	ttl time.Duration
+}
+
+// New returns a store that keeps the accounts in db. Logins expire after
+// ttl, or DefaultTokenTTL when it is zero.
+func New(db *bolt.DB, ttl time.Duration) (*Store, error) {
+	if err := storage.CreateBuckets(db, usersBucket, tokensBucket); err != nil {
+		return nil, err
+	}
+	if ttl == 0 {
+		ttl = DefaultTokenTTL
+	}
+	return &Store{db: db, ttl: ttl}, nil
+}
+
+// CreateUser adds an account with a bcrypt hash of its password. Usernames
+// are lowercase letters, digits and dashes.
+func (s *Store) CreateUser(username, password string, admin bool) (User, error) {
+	if !usernamePattern.MatchString(username) {
+		return User{}, fmt.Errorf("invalid username %q: use up to 32 lowercase letters, digits and dashes", username)
+	}
+	if reservedUsername(username) {
+		return User{}, fmt.Errorf("username %q is reserved", username)
+	}
+	if len(password) < minPasswordLength {
+		return User{}, fmt.Errorf("password must have at least %d characters", minPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, err
+	}
+
+	user := User{Username: username, Admin: admin, CreatedAt: time.Now()}
+	data, err := json.Marshal(storedUser{User: user, PasswordHash: hash})
+	if err != nil {
+		return User{}, err
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(usersBucket)
+		if b.Get([]byte(username)) != nil {
+			return ErrUserExists
+		}
+		return b.Put([]byte(username), data)
+	})
+	return user, err
+}
+
+// HasUsers reports whether any account exists.
+func (s *Store) HasUsers() (bool, error) {
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		k, _ := tx.Bucket(usersBucket).Cursor().First()
+		found = k != nil
+		return nil
+	})
+	return found, err
+}
+
+// Login checks the password of a user and returns a new token for it.
+func (s *Store) Login(username, password string) (string, User, error) {
+	user, err := s.user(username)
+	if err != nil {
+		return "", User{}, err
+	}
+	if bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password)) != nil {
+		return "", User{}, ErrInvalidCredentials
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", User{}, err
+	}
+	raw := hex.EncodeToString(secret)
+	data, err := json.Marshal(token{Username: username, ExpiresAt: time.Now().Add(s.ttl)})
+	if err != nil {
+		return "", User{}, err
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(tokensBucket).Put(hashToken(raw), data)
+	})
+	return raw, user.User, err
+}
+
+// Authenticate returns the user a token belongs to. Expired tokens are
+// removed.
+func (s *Store) Authenticate(raw string) (User, error) {
+	if raw == "" {
+		return User{}, ErrUnauthenticated
+	}
+	var t token
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(tokensBucket).Get(hashToken(raw))
+		if data == nil {
+			return ErrUnauthenticated
+		}
+		return json.Unmarshal(data, &t)
+	})
+	if err != nil {
+		return User{}, err
+	}
+	if time.Now().After(t.ExpiresAt) {
+		s.Logout(raw)
+		return User{}, ErrUnauthenticated
+	}
+
+	user, err := s.user(t.Username)
+	if errors.Is(err, ErrInvalidCredentials) {
+		return User{}, ErrUnauthenticated
+	}
+	return user.User, err
+}
+
+// Logout revokes a token.
+func (s *Store) Logout(raw string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(tokensBucket).Delete(hashToken(raw))
+	})
+}
+
+func (s *Store) user(username string) (storedUser, error) {
+	var u storedUser
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(usersBucket).Get([]byte(username))
+		if data == nil {
+			return ErrInvalidCredentials
+		}
+		return json.Unmarshal(data, &u)
+	})
+	return u, err
+}
+
+func hashToken(raw string) []byte {
+	sum := sha256.Sum256([]byte(raw))
+	return sum[:]
+}
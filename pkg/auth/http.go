@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// CookieName is the cookie that carries the login token of the browser.
+const CookieName = "kubelearn_token"
+
+type contextKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user.
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, contextKey{}, user)
+}
+
+// FromContext returns the authenticated user carried by ctx.
+func FromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(contextKey{}).(User)
+	return user, ok
+}
+
+// Token returns the login token of a request, taken from a bearer
+// Authorization header or from the login cookie.
+func Token(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	if cookie, err := r.Cookie(CookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
@@ -0,0 +1,392 @@
+// Package command parses shell command lines as typed in the terminal, with
+// special knowledge of kubectl.
+package command
+
+import (
+	"path"
+	"strings"
+)
+
+// Invocation is one simple command of a command line, e.g. a stage of a
+// pipeline.
+type Invocation struct {
+	// Program is the name of the command without its directory, e.g.
+	// kubectl.
+	Program string   `json:"program"`
+	Args    []string `json:"args,omitempty"`
+	// Kubectl is the parsed kubectl invocation when Program is kubectl or
+	// its common alias k.
+	Kubectl *Kubectl `json:"kubectl,omitempty"`
+}
+
+// prefixes are reserved words that may come before a command, e.g. the do
+// of a loop.
+var prefixes = map[string]bool{
+	"!":     true,
+	"{":     true,
+	"do":    true,
+	"elif":  true,
+	"else":  true,
+	"if":    true,
+	"then":  true,
+	"until": true,
+	"while": true,
+}
+
+// wrapper describes the arguments of a command that runs the command given
+// in its arguments, so that they can be skipped.
+type wrapper struct {
+	// values are the short options that take a value, e.g. the u of
+	// sudo -u root.
+	values string
+	// longValues are the long options that take a value when it is not
+	// attached with =, e.g. --user of sudo.
+	longValues []string
+	// operands is the number of arguments between the options and the
+	// command, e.g. the duration of timeout.
+	operands int
+	// lookups are the short options with which the wrapper only looks the
+	// command up instead of running it, e.g. the v of command -v.
+	lookups string
+}
+
+// wrappers run the command given in their arguments.
+var wrappers = map[string]wrapper{
+	"sudo": {
+		values:     "CDghpRrTtUu",
+		longValues: []string{"--chdir", "--chroot", "--close-from", "--command-timeout", "--group", "--host", "--other-user", "--prompt", "--role", "--type", "--user"},
+	},
+	"time": {
+		values:     "fo",
+		longValues: []string{"--format", "--output"},
+	},
+	"builtin": {},
+	"command": {lookups: "vV"},
+	"exec":    {values: "a"},
+	"env": {
+		values:     "CSu",
+		longValues: []string{"--chdir", "--split-string", "--unset"},
+	},
+	"nohup": {},
+	"nice": {
+		values:     "n",
+		longValues: []string{"--adjustment"},
+	},
+	"timeout": {
+		values:     "ks",
+		longValues: []string{"--kill-after", "--signal"},
+		operands:   1,
+	},
+}
+
+// skip returns the command given in args, the arguments of the wrapper, or
+// nil when they give none or do not run it.
+func (w wrapper) skip(args []string) []string {
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--" {
+			args = args[1:]
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			break
+		}
+		args = args[1:]
+		if strings.HasPrefix(arg, "--") {
+			if !strings.Contains(arg, "=") && contains(w.longValues, arg) && len(args) > 0 {
+				args = args[1:]
+			}
+			continue
+		}
+		// Short options may be grouped, e.g. sudo -Eu root; the value of
+		// the last one is the next argument unless it is attached.
+		for i := 1; i < len(arg); i++ {
+			if strings.IndexByte(w.lookups, arg[i]) >= 0 {
+				return nil
+			}
+			if strings.IndexByte(w.values, arg[i]) >= 0 {
+				if i == len(arg)-1 && len(args) > 0 {
+					args = args[1:]
+				}
+				break
+			}
+		}
+	}
+	if len(args) <= w.operands {
+		return nil
+	}
+	return args[w.operands:]
+}
+
+// Parse splits a command line into its simple commands. Pipelines, lists
+// (;, &&, ||, &), parentheses and newlines separate commands, so subshells
+// are parsed too, and the commands of command substitutions, $(...) and
+// `...`, come before the command they are part of, even inside double
+// quotes. The arguments of eval are parsed as the command line they run.
+// Quotes and backslashes are resolved, redirections and variable
+// assignments are dropped, and wrappers such as sudo and env are skipped
+// with their options. Expansions are kept as typed.
+func Parse(line string) []Invocation {
+	var invocations []Invocation
+	for _, words := range split(line) {
+		inv, ok := invocation(words)
+		if !ok {
+			continue
+		}
+		if inv.Program == "eval" && len(inv.Args) > 0 {
+			invocations = append(invocations, Parse(strings.Join(inv.Args, " "))...)
+			continue
+		}
+		invocations = append(invocations, inv)
+	}
+	return invocations
+}
+
+func invocation(words []string) (Invocation, bool) {
+	for len(words) > 0 {
+		w := words[0]
+		if isAssignment(w) || prefixes[w] && len(words) > 1 {
+			words = words[1:]
+			continue
+		}
+		if wrapper, ok := wrappers[w]; ok {
+			// A wrapper without a command, e.g. sudo -l, is the program.
+			if command := wrapper.skip(words[1:]); command != nil {
+				words = command
+				continue
+			}
+		}
+		break
+	}
+	if len(words) == 0 {
+		return Invocation{}, false
+	}
+	inv := Invocation{Program: path.Base(words[0]), Args: words[1:]}
+	if inv.Program == "kubectl" || inv.Program == "k" {
+		inv.Kubectl = ParseKubectl(inv.Args)
+	}
+	return inv, true
+}
+
+func isAssignment(word string) bool {
+	i := strings.IndexByte(word, '=')
+	if i <= 0 {
+		return false
+	}
+	for j, c := range word[:i] {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || j > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// split tokenizes a command line into the words of its simple commands.
+func split(line string) [][]string {
+	var (
+		commands [][]string
+		words    []string
+		word     strings.Builder
+		inWord   bool
+		redirect bool
+	)
+	endWord := func() {
+		if !inWord {
+			return
+		}
+		if redirect {
+			redirect = false
+		} else {
+			words = append(words, word.String())
+		}
+		word.Reset()
+		inWord = false
+	}
+	endCommand := func() {
+		endWord()
+		if len(words) > 0 {
+			commands = append(commands, words)
+		}
+		words = nil
+		redirect = false
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\':
+			if i+1 < len(line) {
+				i++
+				if line[i] != '\n' {
+					word.WriteByte(line[i])
+					inWord = true
+				}
+			}
+		case c == '\'':
+			inWord = true
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				word.WriteString(line[i+1:])
+				i = len(line)
+				break
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			inWord = true
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$`\n", line[i+1]) >= 0 {
+					i++
+				} else if end, inner, ok := substitution(line, i); ok {
+					commands = append(commands, split(inner)...)
+					word.WriteString(line[i:end])
+					i = end - 1
+					continue
+				}
+				word.WriteByte(line[i])
+			}
+		case c == '#' && !inWord:
+			// A comment runs to the end of the line.
+			for i < len(line) && line[i] != '\n' {
+				i++
+			}
+			endCommand()
+		case c == ' ' || c == '\t':
+			endWord()
+		case c == '$' || c == '`':
+			inWord = true
+			end, inner, ok := substitution(line, i)
+			if !ok {
+				word.WriteByte(c)
+				break
+			}
+			commands = append(commands, split(inner)...)
+			word.WriteString(line[i:end])
+			i = end - 1
+		case c == '(' || c == ')':
+			endCommand()
+		case c == '\n' || c == ';' || c == '|' || c == '&':
+			if c == '&' && i+1 < len(line) && line[i+1] == '>' {
+				// &> redirects both outputs.
+				endWord()
+				i++
+				redirect = true
+				if i+1 < len(line) && line[i+1] == '>' {
+					i++
+				}
+				break
+			}
+			endCommand()
+			if i+1 < len(line) && (line[i+1] == c && c != '\n' || c == '|' && line[i+1] == '&') {
+				i++
+			}
+		case c == '>' || c == '<':
+			// Drop a descriptor number written before the operator, e.g. 2>.
+			if inWord && isDigits(word.String()) {
+				word.Reset()
+				inWord = false
+			}
+			endWord()
+			for i+1 < len(line) && (line[i+1] == '>' || line[i+1] == '<' || line[i+1] == '|') {
+				i++
+			}
+			if i+1 < len(line) && line[i+1] == '&' {
+				// >&2 duplicates a descriptor and takes no file.
+				i++
+				for i+1 < len(line) && line[i+1] >= '0' && line[i+1] <= '9' {
+					i++
+				}
+				break
+			}
+			redirect = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endCommand()
+	return commands
+}
+
+func contains(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+// substitution reports whether a command substitution, $(...) or `...`,
+// starts at line[i], and returns its end and the command line inside it.
+// Arithmetic expansions, $((...)), are kept as a whole but have no
+// commands. A substitution that is not closed runs to the end of line.
+func substitution(line string, i int) (end int, inner string, ok bool) {
+	if line[i] == '`' {
+		for j := i + 1; j < len(line); j++ {
+			switch line[j] {
+			case '\\':
+				j++
+			case '`':
+				return j + 1, line[i+1 : j], true
+			}
+		}
+		return len(line), line[i+1:], true
+	}
+	if !strings.HasPrefix(line[i:], "$(") {
+		return 0, "", false
+	}
+	end = closingParen(line, i+1)
+	if strings.HasPrefix(line[i:], "$((") {
+		return end, "", true
+	}
+	inner = line[i+2:]
+	if end <= len(line) && line[end-1] == ')' {
+		inner = line[i+2 : end-1]
+	}
+	return end, inner, true
+}
+
+// closingParen returns the index after the parenthesis that closes the one
+// at line[open], skipping quoted text, or len(line) if it is not closed.
+func closingParen(line string, open int) int {
+	depth := 0
+	for j := open; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+		case '\'':
+			if end := strings.IndexByte(line[j+1:], '\''); end >= 0 {
+				j += end + 1
+			} else {
+				return len(line)
+			}
+		case '"':
+			for j++; j < len(line) && line[j] != '"'; j++ {
+				if line[j] == '\\' {
+					j++
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return len(line)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,116 @@
+package command
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		// want are the programs and arguments of the invocations.
+		want [][]string
+	}{
+		{"kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"/usr/local/bin/kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"", nil},
+		{"# just a comment", nil},
+
+		// Lists, pipelines and subshells.
+		{"kubectl get pods | grep web && echo ok", [][]string{{"kubectl", "get", "pods"}, {"grep", "web"}, {"echo", "ok"}}},
+		{"cd /tmp; ls || true", [][]string{{"cd", "/tmp"}, {"ls"}, {"true"}}},
+		{"kubectl logs web |& less", [][]string{{"kubectl", "logs", "web"}, {"less"}}},
+		{"sleep 10 &", [][]string{{"sleep", "10"}}},
+		{"ls\nkubectl get pods", [][]string{{"ls"}, {"kubectl", "get", "pods"}}},
+		{"kubectl get pods # the pods", [][]string{{"kubectl", "get", "pods"}}},
+
+		// Command substitutions.
+		{"echo $(kubectl get ns)", [][]string{{"kubectl", "get", "ns"}, {"echo", "$(kubectl get ns)"}}},
+		{`echo "$(kubectl delete ns kube-system)"`, [][]string{{"kubectl", "delete", "ns", "kube-system"}, {"echo", "$(kubectl delete ns kube-system)"}}},
+		{"echo `kubectl delete ns kube-system`", [][]string{{"kubectl", "delete", "ns", "kube-system"}, {"echo", "`kubectl delete ns kube-system`"}}},
+		{"echo \"`kubectl get ns`\"", [][]string{{"kubectl", "get", "ns"}, {"echo", "`kubectl get ns`"}}},
+		{`echo "$(echo "$(kubectl delete ns web)")"`, [][]string{{"kubectl", "delete", "ns", "web"}, {"echo", `$(kubectl delete ns web)`}, {"echo", `$(echo "$(kubectl delete ns web)")`}}},
+		{`x=$(kubectl get pods -o name | wc -l)`, [][]string{{"kubectl", "get", "pods", "-o", "name"}, {"wc", "-l"}}},
+		{`echo $(kubectl get ns`, [][]string{{"kubectl", "get", "ns"}, {"echo", "$(kubectl get ns"}}},
+		{"echo $((1 + 2)) $HOME", [][]string{{"echo", "$((1 + 2))", "$HOME"}}},
+		{`echo '$(kubectl get ns)'`, [][]string{{"echo", "$(kubectl get ns)"}}},
+		{`echo \$(kubectl get ns)`, [][]string{{"echo", "$"}, {"kubectl", "get", "ns"}}},
+
+		// eval runs its arguments.
+		{`eval "kubectl delete ns kube-system"`, [][]string{{"kubectl", "delete", "ns", "kube-system"}}},
+		{`eval kubectl get pods '|' grep web`, [][]string{{"kubectl", "get", "pods"}, {"grep", "web"}}},
+		{`builtin eval "rm -rf ~"`, [][]string{{"rm", "-rf", "~"}}},
+		{"eval", [][]string{{"eval"}}},
+
+		// Quotes and backslashes.
+		{`echo 'a b' "c d"`, [][]string{{"echo", "a b", "c d"}}},
+		{`echo "say \"hi\"" a\ b`, [][]string{{"echo", `say "hi"`, "a b"}}},
+		{`echo 'unterminated`, [][]string{{"echo", "unterminated"}}},
+		{"kubectl get \\\npods", [][]string{{"kubectl", "get", "pods"}}},
+		{`echo a#b`, [][]string{{"echo", "a#b"}}},
+
+		// Redirections.
+		{"kubectl get pods > pods.txt", [][]string{{"kubectl", "get", "pods"}}},
+		{"kubectl apply -f - < pod.yaml 2>/dev/null", [][]string{{"kubectl", "apply", "-f", "-"}}},
+		{"kubectl get pods 2>&1", [][]string{{"kubectl", "get", "pods"}}},
+		{"kubectl get pods &> out.txt", [][]string{{"kubectl", "get", "pods"}}},
+		{"cat <<EOF", [][]string{{"cat"}}},
+
+		// Assignments and reserved words.
+		{"KUBECONFIG=/tmp/config kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"FOO=bar", nil},
+		{"if true; then kubectl get pods", [][]string{{"true"}, {"kubectl", "get", "pods"}}},
+		{"while true; do kubectl get pods", [][]string{{"true"}, {"kubectl", "get", "pods"}}},
+		{"(kubectl get pods)", [][]string{{"kubectl", "get", "pods"}}},
+		{"! kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+
+		// Wrappers and their options.
+		{"sudo kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"sudo -E kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"sudo -u root kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"sudo -uroot kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"sudo -Eu root kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"sudo -g admin -u root kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"sudo --user root kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"sudo --user=root kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"sudo -- kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"sudo -l", [][]string{{"sudo", "-l"}}},
+		{"sudo", [][]string{{"sudo"}}},
+		{"env -u KUBECONFIG kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"env -i PATH=/bin kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"env --chdir /tmp kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"env", [][]string{{"env"}}},
+		{"timeout 10s kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"timeout -s KILL -k 5 10s kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"timeout --signal=KILL 10s kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"timeout 10s", [][]string{{"timeout", "10s"}}},
+		{"nice -n 10 kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"time -p kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"time -f %e kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"nohup sudo -u root timeout 5 kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+		{"exec -a shell bash", [][]string{{"bash"}}},
+		{"command -v kubectl", [][]string{{"command", "-v", "kubectl"}}},
+		{"command -V kubectl", [][]string{{"command", "-V", "kubectl"}}},
+		{"command -pv kubectl", [][]string{{"command", "-pv", "kubectl"}}},
+		{"command -p kubectl get pods", [][]string{{"kubectl", "get", "pods"}}},
+	}
+	for _, tt := range tests {
+		var got [][]string
+		for _, inv := range Parse(tt.line) {
+			got = append(got, append([]string{inv.Program}, inv.Args...))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseKubectl(t *testing.T) {
+	inv := Parse("sudo -u root k -n kube-system delete pod web")
+	if len(inv) != 1 || inv[0].Program != "k" || inv[0].Kubectl == nil {
+		t.Fatalf("Parse = %+v, want one kubectl invocation", inv)
+	}
+	if k := inv[0].Kubectl; k.Verb != "delete" || k.Namespace != "kube-system" {
+		t.Errorf("Kubectl = %+v, want delete in kube-system", k)
+	}
+}
@@ -0,0 +1,327 @@
+package command
+
+import "strings"
+
+// Kubectl is a parsed kubectl invocation, e.g. kubectl run nginx
+// --image=nginx --dry-run=client -o yaml has the verb run, the kind pods,
+// the names [nginx] and the flags image, dry-run and output.
+type Kubectl struct {
+	Verb string `json:"verb,omitempty"`
+	// Subcommand is the second word of commands such as rollout status or
+	// config use-context.
+	Subcommand string `json:"subcommand,omitempty"`
+	// Kind is the resource type in its plural form, e.g. deployments for
+	// deploy. Several types are separated by commas as on the command line,
+	// or as given with the names, e.g. for kubectl get deploy/web svc/web.
+	Kind string `json:"kind,omitempty"`
+	// Names are the names of the resources, e.g. a and b for both kubectl
+	// delete pods a b and kubectl delete pod/a pod/b.
+	Names []string `json:"names,omitempty"`
+	// Namespace is the namespace given with -n, or * for
+	// --all-namespaces. It is empty for the kubeconfig's namespace.
+	Namespace string `json:"namespace,omitempty"`
+	// Flags maps the long names of the given flags to their values; flags
+	// without a value map to "true".
+	Flags map[string]string `json:"flags,omitempty"`
+	// Command is what follows --, e.g. the command of kubectl exec.
+	Command []string `json:"command,omitempty"`
+}
+
+// shortFlags maps the short flags of kubectl to their long names.
+var shortFlags = map[string]string{
+	"A": "all-namespaces",
+	"c": "container",
+	"f": "filename",
+	"i": "stdin",
+	"k": "kustomize",
+	"l": "selector",
+	"L": "label-columns",
+	"n": "namespace",
+	"o": "output",
+	"R": "recursive",
+	"t": "tty",
+	"w": "watch",
+}
+
+// logsShortFlags are the short flags whose meaning differs for kubectl logs.
+var logsShortFlags = map[string]string{
+	"f": "follow",
+	"p": "previous",
+}
+
+// boolFlags take no value unless it is given with =.
+var boolFlags = map[string]bool{
+	"all":                      true,
+	"all-containers":           true,
+	"all-namespaces":           true,
+	"delete-emptydir-data":     true,
+	"follow":                   true,
+	"force":                    true,
+	"ignore-daemonsets":        true,
+	"ignore-not-found":         true,
+	"insecure-skip-tls-verify": true,
+	"local":                    true,
+	"no-headers":               true,
+	"now":                      true,
+	"overwrite":                true,
+	"previous":                 true,
+	"quiet":                    true,
+	"record":                   true,
+	"recursive":                true,
+	"rm":                       true,
+	"save-config":              true,
+	"server-side":              true,
+	"show-kind":                true,
+	"show-labels":              true,
+	"stdin":                    true,
+	"timestamps":               true,
+	"tty":                      true,
+	"watch":                    true,
+	"watch-only":               true,
+	"wait":                     true,
+}
+
+// subcommandVerbs take a subcommand before their arguments. The resource
+// of the subcommand follows for those that act on resources.
+var subcommandVerbs = map[string]bool{
+	"auth":        false,
+	"certificate": false,
+	"config":      false,
+	"plugin":      false,
+	"rollout":     true,
+	"set":         true,
+	"top":         false,
+}
+
+// kinds maps short names and singular forms of common resource types to
+// their plural names.
+var kinds = map[string]string{
+	"cj":                       "cronjobs",
+	"cm":                       "configmaps",
+	"clusterrole":              "clusterroles",
+	"clusterrolebinding":       "clusterrolebindings",
+	"configmap":                "configmaps",
+	"cronjob":                  "cronjobs",
+	"crd":                      "customresourcedefinitions",
+	"crds":                     "customresourcedefinitions",
+	"customresourcedefinition": "customresourcedefinitions",
+	"daemonset":                "daemonsets",
+	"deploy":                   "deployments",
+	"deployment":               "deployments",
+	"ds":                       "daemonsets",
+	"endpoint":                 "endpoints",
+	"ep":                       "endpoints",
+	"ev":                       "events",
+	"event":                    "events",
+	"hpa":                      "horizontalpodautoscalers",
+	"horizontalpodautoscaler":  "horizontalpodautoscalers",
+	"ing":                      "ingresses",
+	"ingress":                  "ingresses",
+	"job":                      "jobs",
+	"limitrange":               "limitranges",
+	"limits":                   "limitranges",
+	"namespace":                "namespaces",
+	"netpol":                   "networkpolicies",
+	"networkpolicy":            "networkpolicies",
+	"no":                       "nodes",
+	"node":                     "nodes",
+	"ns":                       "namespaces",
+	"pdb":                      "poddisruptionbudgets",
+	"persistentvolume":         "persistentvolumes",
+	"persistentvolumeclaim":    "persistentvolumeclaims",
+	"po":                       "pods",
+	"pod":                      "pods",
+	"poddisruptionbudget":      "poddisruptionbudgets",
+	"pv":                       "persistentvolumes",
+	"pvc":                      "persistentvolumeclaims",
+	"quota":                    "resourcequotas",
+	"replicaset":               "replicasets",
+	"resourcequota":            "resourcequotas",
+	"role":                     "roles",
+	"rolebinding":              "rolebindings",
+	"rs":                       "replicasets",
+	"sa":                       "serviceaccounts",
+	"sc":                       "storageclasses",
+	"secret":                   "secrets",
+	"service":                  "services",
+	"serviceaccount":           "serviceaccounts",
+	"statefulset":              "statefulsets",
+	"storageclass":             "storageclasses",
+	"sts":                      "statefulsets",
+	"svc":                      "services",
+}
+
+// keyValueVerbs take key=value arguments, or key- to remove a key, after
+// the resources, e.g. kubectl label pods web tier=frontend.
+var keyValueVerbs = map[string]bool{"annotate": true, "label": true, "set": true, "taint": true}
+
+// secretTypes are the subcommands of kubectl create secret.
+var secretTypes = map[string]bool{"generic": true, "tls": true, "docker-registry": true}
+
+// ParseKubectl parses the arguments of kubectl.
+func ParseKubectl(args []string) *Kubectl {
+	k := &Kubectl{Flags: map[string]string{}}
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			k.Command = args[i+1:]
+			i = len(args)
+		case strings.HasPrefix(arg, "--"):
+			name, value, ok := strings.Cut(arg[2:], "=")
+			if !ok {
+				value = "true"
+				if !boolFlags[name] && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+					i++
+					value = args[i]
+				}
+			}
+			k.Flags[name] = value
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			i = k.shortFlag(args, i, len(positional) > 0 && positional[0] == "logs")
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	switch {
+	case k.Flags["all-namespaces"] == "true":
+		k.Namespace = "*"
+	case k.Flags["namespace"] != "":
+		k.Namespace = k.Flags["namespace"]
+	}
+	if len(k.Flags) == 0 {
+		k.Flags = nil
+	}
+	if len(positional) == 0 {
+		return k
+	}
+
+	k.Verb, positional = positional[0], positional[1:]
+	if resources, ok := subcommandVerbs[k.Verb]; ok {
+		if len(positional) > 0 {
+			k.Subcommand, positional = positional[0], positional[1:]
+		}
+		if k.Verb == "top" {
+			// The subcommand of top is the resource type.
+			k.Kind = KindName(k.Subcommand)
+			if len(positional) > 0 {
+				k.Names = positional
+			}
+		}
+		if !resources {
+			return k
+		}
+	}
+
+	switch k.Verb {
+	case "run":
+		k.Kind = "pods"
+		if len(positional) > 0 {
+			k.Names = []string{positional[0]}
+		}
+		return k
+	case "exec", "logs", "attach", "port-forward":
+		if len(positional) > 0 {
+			k.Kind, k.Names = "pods", []string{positional[0]}
+			if kind, name, ok := strings.Cut(positional[0], "/"); ok {
+				k.Kind, k.Names = KindName(kind), []string{name}
+			}
+		}
+		return k
+	case "explain":
+		if len(positional) > 0 {
+			kind, _, _ := strings.Cut(positional[0], ".")
+			k.Kind = KindName(kind)
+		}
+		return k
+	case "create":
+		if len(positional) > 0 && positional[0] == "secret" && len(positional) > 1 && secretTypes[positional[1]] {
+			k.Subcommand = positional[1]
+			positional = append(positional[:1], positional[2:]...)
+		}
+	}
+
+	if keyValueVerbs[k.Verb] {
+		positional = resources(positional)
+	}
+	if len(positional) == 0 {
+		return k
+	}
+	if !strings.Contains(positional[0], "/") {
+		k.Kind = KindName(positional[0])
+		if len(positional) > 1 {
+			k.Names = positional[1:]
+		}
+		return k
+	}
+	// Every resource is given as type/name.
+	var kinds []string
+	for _, arg := range positional {
+		kind, name, _ := strings.Cut(arg, "/")
+		if kind = KindName(kind); !contains(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+		k.Names = append(k.Names, name)
+	}
+	k.Kind = strings.Join(kinds, ",")
+	return k
+}
+
+// resources drops the key=value and key- arguments of verbs such as label.
+func resources(args []string) []string {
+	var out []string
+	for _, arg := range args {
+		if !strings.Contains(arg, "=") && !strings.HasSuffix(arg, "-") {
+			out = append(out, arg)
+		}
+	}
+	return out
+}
+
+// shortFlag parses the short flags in args[i], such as -n ns, -oyaml or
+// -it, and returns the index of the last argument it used.
+func (k *Kubectl) shortFlag(args []string, i int, logs bool) int {
+	letters := args[i][1:]
+	for j := 0; j < len(letters); j++ {
+		letter := letters[j : j+1]
+		name := shortFlags[letter]
+		if logs && logsShortFlags[letter] != "" {
+			name = logsShortFlags[letter]
+		}
+		if name == "" {
+			name = letter
+		}
+		if boolFlags[name] || (shortFlags[letter] == "" && name == letter) {
+			k.Flags[name] = "true"
+			continue
+		}
+		// The rest of the argument, or the next one, is the value.
+		value := strings.TrimPrefix(letters[j+1:], "=")
+		if value == "" && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		k.Flags[name] = value
+		return i
+	}
+	return i
+}
+
+// KindName returns the plural name of a resource type, which may include
+// several comma-separated types and an API group.
+func KindName(kind string) string {
+	parts := strings.Split(strings.ToLower(kind), ",")
+	for i, part := range parts {
+		name, group, _ := strings.Cut(part, ".")
+		if plural, ok := kinds[name]; ok {
+			name = plural
+		}
+		if group != "" {
+			name += "." + group
+		}
+		parts[i] = name
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,42 @@
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseKubectlArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want Kubectl
+	}{
+		{"get pods", Kubectl{Verb: "get", Kind: "pods"}},
+		{"get po web", Kubectl{Verb: "get", Kind: "pods", Names: []string{"web"}}},
+		{"delete ns default kube-system", Kubectl{Verb: "delete", Kind: "namespaces", Names: []string{"default", "kube-system"}}},
+		{"delete pod/a pod/b", Kubectl{Verb: "delete", Kind: "pods", Names: []string{"a", "b"}}},
+		{"get deploy/web svc/web", Kubectl{Verb: "get", Kind: "deployments,services", Names: []string{"web", "web"}}},
+		{"get pods,svc", Kubectl{Verb: "get", Kind: "pods,services"}},
+		{"run nginx --image=nginx --dry-run=client -o yaml", Kubectl{Verb: "run", Kind: "pods", Names: []string{"nginx"},
+			Flags: map[string]string{"image": "nginx", "dry-run": "client", "output": "yaml"}}},
+		{"-n kube-system delete pod web", Kubectl{Verb: "delete", Kind: "pods", Names: []string{"web"}, Namespace: "kube-system",
+			Flags: map[string]string{"namespace": "kube-system"}}},
+		{"get pods -A", Kubectl{Verb: "get", Kind: "pods", Namespace: "*", Flags: map[string]string{"all-namespaces": "true"}}},
+		{"exec -it web -- sh", Kubectl{Verb: "exec", Kind: "pods", Names: []string{"web"},
+			Flags: map[string]string{"stdin": "true", "tty": "true"}, Command: []string{"sh"}}},
+		{"logs deploy/web -f", Kubectl{Verb: "logs", Kind: "deployments", Names: []string{"web"}, Flags: map[string]string{"follow": "true"}}},
+		{"label pods web db tier=frontend app-", Kubectl{Verb: "label", Kind: "pods", Names: []string{"web", "db"}}},
+		{"rollout status deploy/web", Kubectl{Verb: "rollout", Subcommand: "status", Kind: "deployments", Names: []string{"web"}}},
+		{"set image deploy/web nginx=nginx:1.25", Kubectl{Verb: "set", Subcommand: "image", Kind: "deployments", Names: []string{"web"}}},
+		{"create secret generic creds --from-literal=a=b", Kubectl{Verb: "create", Subcommand: "generic", Kind: "secrets", Names: []string{"creds"},
+			Flags: map[string]string{"from-literal": "a=b"}}},
+		{"config use-context kind", Kubectl{Verb: "config", Subcommand: "use-context"}},
+		{"top pods web", Kubectl{Verb: "top", Subcommand: "pods", Kind: "pods", Names: []string{"web"}}},
+		{"explain deployment.spec", Kubectl{Verb: "explain", Kind: "deployments"}},
+	}
+	for _, tt := range tests {
+		if got := ParseKubectl(strings.Fields(tt.line)); !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("ParseKubectl(%q) = %+v, want %+v", tt.line, *got, tt.want)
+		}
+	}
+}
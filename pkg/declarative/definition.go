@@ -0,0 +1,127 @@
+package declarative
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"kubelearn/pkg/assertion"
+	"kubelearn/pkg/registry"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// Definition is a question written in YAML or JSON instead of Go.
+type Definition struct {
+	ID         int         `json:"id"`
+	Prompt     string      `json:"prompt"`
+	Difficulty string      `json:"difficulty"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Tags       []string    `json:"tags,omitempty"`
+	Hints      []string    `json:"hints,omitempty"`
+	Target     Target      `json:"target"`
+	Assertions []Assertion `json:"assertions"`
+	// Setup holds the manifests applied before the learner starts.
+	Setup string `json:"setup,omitempty"`
+}
+
+// Target is the resource the assertions are evaluated against.
+type Target struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// GroupVersionKind parses the apiVersion and kind of the target.
+func (t Target) GroupVersionKind() schema.GroupVersionKind {
+	return schema.FromAPIVersionAndKind(t.APIVersion, t.Kind)
+}
+
+// Assertion is a single graded condition on the target resource.
+type Assertion struct {
+	Name string `json:"name"`
+	// JSONPath is a kubectl-style template such as {.spec.replicas}.
+	JSONPath string `json:"jsonPath,omitempty"`
+	// Equals is the expected output of the JSONPath template. When omitted
+	// the assertion only requires the template to produce some output.
+	Equals *string `json:"equals,omitempty"`
+	// CEL is a boolean expression evaluated with the target bound to object,
+	// such as object.spec.replicas >= 3.
+	CEL string `json:"cel,omitempty"`
+}
+
+// Validate reports the first problem with the definition.
+func (d Definition) Validate() error {
+	switch {
+	case d.ID <= 0:
+		return errors.New("id must be a positive number")
+	case d.Prompt == "":
+		return errors.New("prompt is required")
+	case d.Difficulty != registry.Easy && d.Difficulty != registry.Medium && d.Difficulty != registry.Hard:
+		return fmt.Errorf("difficulty must be %s, %s or %s", registry.Easy, registry.Medium, registry.Hard)
+	case d.Target.APIVersion == "" || d.Target.Kind == "" || d.Target.Name == "":
+		return errors.New("target needs apiVersion, kind and name")
+	case len(d.Assertions) == 0:
+		return errors.New("at least one assertion is required")
+	}
+	for i, a := range d.Assertions {
+		if a.Name == "" {
+			return fmt.Errorf("assertion %d has no name", i)
+		}
+		if (a.JSONPath == "") == (a.CEL == "") {
+			return fmt.Errorf("assertion %q needs either a jsonPath or a cel expression", a.Name)
+		}
+		if a.JSONPath != "" {
+			if err := jsonpath.New(a.Name).Parse(a.JSONPath); err != nil {
+				return fmt.Errorf("assertion %q: %w", a.Name, err)
+			}
+		}
+		if a.CEL != "" {
+			if err := assertion.Compile(a.CEL); err != nil {
+				return fmt.Errorf("assertion %q: %w", a.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Parse decodes and validates a YAML or JSON question definition.
+func Parse(data []byte) (Definition, error) {
+	var def Definition
+	if err := yaml.UnmarshalStrict(data, &def); err != nil {
+		return def, err
+	}
+	return def, def.Validate()
+}
+
+// LoadDir parses every .yaml, .yml and .json file in dir into questions.
+func LoadDir(dir string) ([]registry.Question, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var questions []registry.Question
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		def, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		questions = append(questions, New(def))
+	}
+	return questions, nil
+}
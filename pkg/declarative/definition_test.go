@@ -0,0 +1,82 @@
+package declarative
+
+import (
+	"strings"
+	"testing"
+)
+
+const valid = `
+id: 90
+prompt: Scale the deployment web to 3 replicas
+difficulty: Easy
+target:
+  apiVersion: apps/v1
+  kind: Deployment
+  namespace: colors
+  name: web
+assertions:
+- name: replicas
+  jsonPath: '{.spec.replicas}'
+  equals: "3"
+- name: image
+  cel: object.spec.template.spec.containers.exists(c, c.image == 'nginx')
+`
+
+func TestParse(t *testing.T) {
+	def, err := Parse([]byte(valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if def.ID != 90 || def.Target.GroupVersionKind().Group != "apps" || len(def.Assertions) != 2 {
+		t.Errorf("Parse = %+v", def)
+	}
+	if a := def.Assertions[0]; a.Equals == nil || *a.Equals != "3" {
+		t.Errorf("assertion %+v, want equals 3", a)
+	}
+
+	json := `{"id": 91, "prompt": "p", "difficulty": "Hard",
+		"target": {"apiVersion": "v1", "kind": "Pod", "name": "web"},
+		"assertions": [{"name": "ip", "jsonPath": "{.status.podIP}"}]}`
+	if _, err := Parse([]byte(json)); err != nil {
+		t.Errorf("Parse(JSON): %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace [2]string
+		err     string
+	}{
+		{"no id", [2]string{"id: 90", "id: 0"}, "id must be a positive number"},
+		{"no prompt", [2]string{"prompt: Scale the deployment web to 3 replicas", "prompt: ''"}, "prompt is required"},
+		{"difficulty", [2]string{"difficulty: Easy", "difficulty: easy"}, "difficulty must be"},
+		{"no target name", [2]string{"  name: web", "  name: ''"}, "target needs"},
+		{"unknown field", [2]string{"difficulty: Easy", "difficulty: Easy\nlevel: 1"}, "unknown field"},
+		{"no assertion name", [2]string{"- name: replicas", "- name: ''"}, "assertion 0 has no name"},
+		{"jsonPath and cel", [2]string{"  equals: \"3\"", "  cel: 'true'"}, "either a jsonPath or a cel expression"},
+		{"jsonPath", [2]string{"{.spec.replicas}", "{.spec.replicas"}, `assertion "replicas"`},
+		{"jsonPath function", [2]string{"{.spec.replicas}", "{.spec[?(@.x ~ 1)]}"}, `assertion "replicas"`},
+		{"cel", [2]string{"c.image == 'nginx'", "c.image =="}, `assertion "image"`},
+	}
+	for _, tt := range tests {
+		data := strings.Replace(valid, tt.replace[0], tt.replace[1], 1)
+		if data == valid {
+			t.Fatalf("%s: %q is not in the definition", tt.name, tt.replace[0])
+		}
+		_, err := Parse([]byte(data))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: Parse error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	questions, err := LoadDir("../../questions_examples")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(questions) == 0 {
+		t.Error("LoadDir found no questions")
+	}
+}
@@ -0,0 +1,96 @@
+package declarative
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"kubelearn/pkg/assertion"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
+	"kubelearn/pkg/utils"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+type question struct {
+	def Definition
+}
+
+// New returns a Question graded by evaluating the assertions of def against
+// its target with the dynamic client.
+func New(def Definition) registry.Question {
+	return &question{def: def}
+}
+
+func (q *question) ID() int                  { return q.def.ID }
+func (q *question) Prompt() string           { return q.def.Prompt }
+func (q *question) Difficulty() string       { return q.def.Difficulty }
+func (q *question) Namespace() string        { return q.def.Namespace }
+func (q *question) CreatedNamespace() string { return "" }
+func (q *question) Tags() []string           { return q.def.Tags }
+func (q *question) Hints() []string          { return q.def.Hints }
+
+func (q *question) Setup(ctx context.Context, clients *k8s.Clients) error {
+	if q.def.Namespace != "" {
+		if err := clients.EnsureNamespace(ctx, q.def.Namespace); err != nil {
+			return err
+		}
+	}
+	if q.def.Setup == "" {
+		return nil
+	}
+	return clients.Apply(ctx, []byte(q.def.Setup))
+}
+
+func (q *question) Cleanup(ctx context.Context, clients *k8s.Clients) error {
+	if q.def.Setup == "" {
+		return nil
+	}
+	return clients.Delete(ctx, []byte(q.def.Setup))
+}
+
+func (q *question) Check(ctx context.Context, clients *k8s.Clients) utils.Result {
+	return registry.NewResult(q, q.evaluate(ctx, clients))
+}
+
+func (q *question) evaluate(ctx context.Context, clients *k8s.Clients) []utils.Criterion {
+	target := q.def.Target
+	name := fmt.Sprintf("%s %s", target.Kind, target.Name)
+
+	ri, err := clients.Resource(ctx, target.GroupVersionKind(), target.Namespace)
+	if err != nil {
+		return []utils.Criterion{utils.Missing(name, err)}
+	}
+	obj, err := ri.Get(ctx, target.Name, metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing(name, err)}
+	}
+
+	var criteria []utils.Criterion
+	for _, a := range q.def.Assertions {
+		if a.CEL != "" {
+			criteria = append(criteria, assertion.Criterion(a.Name, a.CEL, obj.Object))
+			continue
+		}
+		criteria = append(criteria, evaluateJSONPath(a, obj.Object))
+	}
+	return criteria
+}
+
+func evaluateJSONPath(a Assertion, object map[string]interface{}) utils.Criterion {
+	j := jsonpath.New(a.Name).AllowMissingKeys(true)
+	if err := j.Parse(a.JSONPath); err != nil {
+		return utils.Criterion{Name: a.Name, Expected: "valid jsonPath", Observed: err.Error()}
+	}
+	var out bytes.Buffer
+	if err := j.Execute(&out, object); err != nil {
+		return utils.Criterion{Name: a.Name, Expected: "valid jsonPath", Observed: err.Error()}
+	}
+
+	if a.Equals == nil {
+		return utils.Criterion{Name: a.Name, Expected: "set", Observed: out.String(), Passed: out.Len() > 0}
+	}
+	return utils.Expect(a.Name, *a.Equals, out.String())
+}
@@ -0,0 +1,69 @@
+package declarative
+
+import (
+	"testing"
+
+	"kubelearn/pkg/utils"
+)
+
+func TestEvaluateJSONPath(t *testing.T) {
+	object := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "sidecar", "image": "busybox"},
+						map[string]interface{}{"name": "web", "image": "nginx"},
+					},
+				},
+			},
+		},
+	}
+	equals := func(s string) *string { return &s }
+
+	tests := []struct {
+		assertion Assertion
+		want      utils.Criterion
+	}{
+		{
+			Assertion{Name: "replicas", JSONPath: "{.spec.replicas}", Equals: equals("3")},
+			utils.Criterion{Name: "replicas", Expected: "3", Observed: "3", Passed: true},
+		},
+		{
+			Assertion{Name: "replicas", JSONPath: "{.spec.replicas}", Equals: equals("5")},
+			utils.Criterion{Name: "replicas", Expected: "5", Observed: "3"},
+		},
+		{
+			Assertion{Name: "image", JSONPath: `{.spec.template.spec.containers[?(@.name=="web")].image}`, Equals: equals("nginx")},
+			utils.Criterion{Name: "image", Expected: "nginx", Observed: "nginx", Passed: true},
+		},
+		{
+			Assertion{Name: "images", JSONPath: "{.spec.template.spec.containers[*].image}", Equals: equals("busybox nginx")},
+			utils.Criterion{Name: "images", Expected: "busybox nginx", Observed: "busybox nginx", Passed: true},
+		},
+		{
+			Assertion{Name: "replicas set", JSONPath: "{.spec.replicas}"},
+			utils.Criterion{Name: "replicas set", Expected: "set", Observed: "3", Passed: true},
+		},
+		{
+			// Missing keys produce no output rather than an error.
+			Assertion{Name: "selector set", JSONPath: "{.spec.selector}"},
+			utils.Criterion{Name: "selector set", Expected: "set", Observed: ""},
+		},
+		{
+			Assertion{Name: "selector", JSONPath: "{.spec.selector}", Equals: equals("")},
+			utils.Criterion{Name: "selector", Expected: "", Observed: "", Passed: true},
+		},
+	}
+	for _, tt := range tests {
+		if got := evaluateJSONPath(tt.assertion, object); got != tt.want {
+			t.Errorf("evaluateJSONPath(%s) = %+v, want %+v", tt.assertion.JSONPath, got, tt.want)
+		}
+	}
+
+	got := evaluateJSONPath(Assertion{Name: "broken", JSONPath: "{.spec"}, object)
+	if got.Passed || got.Expected != "valid jsonPath" {
+		t.Errorf("evaluateJSONPath of an invalid template = %+v", got)
+	}
+}
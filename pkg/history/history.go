@@ -0,0 +1,262 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"kubelearn/pkg/scoring"
+	"kubelearn/pkg/session"
+	"kubelearn/pkg/storage"
+	"kubelearn/pkg/utils"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var attemptsBucket = []byte("attempts")
+
+// ErrNotFound is returned for an attempt that is not in the history.
+var ErrNotFound = errors.New("attempt not found")
+
+// Attempt is a finished quiz session as kept in the history.
+type Attempt struct {
+	ID             string         `json:"id"`
+	User           string         `json:"user"`
+	State          session.State  `json:"state"`
+	StartedAt      time.Time      `json:"startedAt"`
+	FinishedAt     time.Time      `json:"finishedAt"`
+	Duration       float64        `json:"durationSeconds"`
+	ClusterVersion string         `json:"clusterVersion"`
+	Report         scoring.Report `json:"report"`
+	Results        []utils.Result `json:"results"`
+	// Checks are the checks the learner ran during the session.
+	Checks []session.Attempt `json:"checks,omitempty"`
+	// Questions, Deadline, TimeLimit and NamespacePrefix complete the
+	// session.
+	Questions       []int     `json:"questions"`
+	Deadline        time.Time `json:"deadline"`
+	TimeLimit       float64   `json:"timeLimitSeconds"`
+	NamespacePrefix string    `json:"namespacePrefix"`
+}
+
+// Summary is the short form of an attempt used in listings.
+type Summary struct {
+	ID             string        `json:"id"`
+	User           string        `json:"user"`
+	State          session.State `json:"state"`
+	StartedAt      time.Time     `json:"startedAt"`
+	FinishedAt     time.Time     `json:"finishedAt"`
+	Duration       float64       `json:"durationSeconds"`
+	ClusterVersion string        `json:"clusterVersion"`
+	Score          float64       `json:"score"`
+	Passed         bool          `json:"passed"`
+}
+
+// Rate is how often a question was passed.
+type Rate struct {
+	Attempts int     `json:"attempts"`
+	Passed   int     `json:"passed"`
+	PassRate float64 `json:"passRate"`
+}
+
+func (r *Rate) add(passed bool) {
+	r.Attempts++
+	if passed {
+		r.Passed++
+	}
+	r.PassRate = float64(r.Passed) / float64(r.Attempts) * 100
+}
+
+// DailyRate is the pass rate of a question on one day.
+type DailyRate struct {
+	Date string `json:"date"`
+	Rate
+}
+
+// QuestionStats is the pass rate of a question overall and per day, oldest
+// day first.
+type QuestionStats struct {
+	Question int    `json:"question"`
+	Title    string `json:"title"`
+	Rate
+	Days []DailyRate `json:"days"`
+}
+
+// NewAttempt converts a graded session into a history attempt.
+func NewAttempt(s session.Session) Attempt {
+	a := Attempt{
+		ID:              s.ID,
+		User:            s.User,
+		State:           s.State,
+		StartedAt:       s.StartedAt,
+		Duration:        s.Duration().Seconds(),
+		ClusterVersion:  s.ClusterVersion,
+		Results:         s.Results,
+		Checks:          s.Attempts,
+		Questions:       s.Questions,
+		Deadline:        s.Deadline,
+		TimeLimit:       s.TimeLimit,
+		NamespacePrefix: s.NamespacePrefix,
+	}
+	if s.FinishedAt != nil {
+		a.FinishedAt = *s.FinishedAt
+	}
+	if s.Report != nil {
+		a.Report = *s.Report
+	}
+	return a
+}
+
+// Session returns the graded session the attempt was saved from.
+func (a Attempt) Session() session.Session {
+	finishedAt := a.FinishedAt
+	report := a.Report
+	s := session.Session{
+		ID:              a.ID,
+		User:            a.User,
+		NamespacePrefix: a.NamespacePrefix,
+		State:           a.State,
+		StartedAt:       a.StartedAt,
+		Deadline:        a.Deadline,
+		TimeLimit:       a.TimeLimit,
+		Questions:       a.Questions,
+		Attempts:        a.Checks,
+		FinishedAt:      &finishedAt,
+		ClusterVersion:  a.ClusterVersion,
+		Results:         a.Results,
+		Report:          &report,
+	}
+	if s.Attempts == nil {
+		s.Attempts = []session.Attempt{}
+	}
+	return s
+}
+
+// Summary returns the short form of the attempt.
+func (a Attempt) Summary() Summary {
+	return Summary{
+		ID:             a.ID,
+		User:           a.User,
+		State:          a.State,
+		StartedAt:      a.StartedAt,
+		FinishedAt:     a.FinishedAt,
+		Duration:       a.Duration,
+		ClusterVersion: a.ClusterVersion,
+		Score:          a.Report.Score,
+		Passed:         a.Report.Passed,
+	}
+}
+
+// Store keeps the finished attempts in a bbolt database.
+type Store struct {
+	db *bolt.DB
+}
+
+// New returns a store that keeps the history in db.
+func New(db *bolt.DB) (*Store, error) {
+	if err := storage.CreateBuckets(db, attemptsBucket); err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Save stores an attempt, replacing any attempt with the same ID.
+func (s *Store) Save(a Attempt) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(attemptsBucket).Put([]byte(a.ID), data)
+	})
+}
+
+// Get returns the attempt with the given ID.
+func (s *Store) Get(id string) (Attempt, error) {
+	var a Attempt
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(attemptsBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, &a)
+	})
+	return a, err
+}
+
+// All returns the attempts of user, or of every user when user is empty,
+// oldest first.
+func (s *Store) All(user string) ([]Attempt, error) {
+	var attempts []Attempt
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(attemptsBucket).ForEach(func(_, data []byte) error {
+			var a Attempt
+			if err := json.Unmarshal(data, &a); err != nil {
+				return err
+			}
+			if user == "" || a.User == user {
+				attempts = append(attempts, a)
+			}
+			return nil
+		})
+	})
+	sort.Slice(attempts, func(i, j int) bool {
+		return attempts[i].FinishedAt.Before(attempts[j].FinishedAt)
+	})
+	return attempts, err
+}
+
+// List returns the summaries of the attempts of user, or of every user when
+// user is empty, newest first.
+func (s *Store) List(user string) ([]Summary, error) {
+	attempts, err := s.All(user)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]Summary, 0, len(attempts))
+	for i := len(attempts) - 1; i >= 0; i-- {
+		summaries = append(summaries, attempts[i].Summary())
+	}
+	return summaries, nil
+}
+
+// PassRates returns the pass rate of every question that was graded in at
+// least one attempt of user, or of any user when user is empty, ordered by
+// question ID.
+func (s *Store) PassRates(user string) ([]QuestionStats, error) {
+	attempts, err := s.All(user)
+	if err != nil {
+		return nil, err
+	}
+	return PassRates(attempts), nil
+}
+
+// PassRates computes the pass rate of each question over the attempts,
+// grouping them per day of the attempt's finish time.
+func PassRates(attempts []Attempt) []QuestionStats {
+	byQuestion := map[int]*QuestionStats{}
+	for _, a := range attempts {
+		date := a.FinishedAt.Format("2006-01-02")
+		for _, result := range a.Results {
+			stats := byQuestion[result.ID]
+			if stats == nil {
+				stats = &QuestionStats{Question: result.ID}
+				byQuestion[result.ID] = stats
+			}
+			stats.Title = result.TestName
+			stats.add(result.Passed)
+			if n := len(stats.Days); n == 0 || stats.Days[n-1].Date != date {
+				stats.Days = append(stats.Days, DailyRate{Date: date})
+			}
+			stats.Days[len(stats.Days)-1].add(result.Passed)
+		}
+	}
+
+	out := make([]QuestionStats, 0, len(byQuestion))
+	for _, stats := range byQuestion {
+		out = append(out, *stats)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Question < out[j].Question })
+	return out
+}
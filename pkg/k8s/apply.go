@@ -0,0 +1,150 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+)
+
+// FieldManager identifies kubelearn as the owner of the fields it applies.
+const FieldManager = "kubelearn"
+
+// deleteTimeout bounds how long Delete waits for objects to go away.
+const deleteTimeout = 2 * time.Minute
+
+// DecodeManifests splits a multi-document YAML or JSON manifest into objects.
+func DecodeManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, err
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+}
+
+// Resource returns the dynamic client for the given kind, scoped to the
+// namespace when the kind is namespaced. The namespace is mapped for ctx
+// with Namespace.
+func (c *Clients) Resource(ctx context.Context, gvk schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	mapping, err := c.Mapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return c.Dynamic().Resource(mapping.Resource), nil
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	return c.Dynamic().Resource(mapping.Resource).Namespace(Namespace(ctx, namespace)), nil
+}
+
+// objectResource returns the dynamic client for a manifest object and maps
+// the object for ctx: namespaced objects move to the mapped namespace and
+// Namespace objects are renamed.
+func (c *Clients) objectResource(ctx context.Context, obj *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := c.Mapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		if gvk.Group == "" && gvk.Kind == "Namespace" {
+			obj.SetName(Namespace(ctx, obj.GetName()))
+		}
+		return c.Dynamic().Resource(mapping.Resource), nil
+	}
+	namespace := obj.GetNamespace()
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	obj.SetNamespace(Namespace(ctx, namespace))
+	return c.Dynamic().Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+}
+
+// Apply creates or updates every object in the manifests with server-side
+// apply, in the namespaces mapped for ctx.
+func (c *Clients) Apply(ctx context.Context, manifests []byte) error {
+	objects, err := DecodeManifests(manifests)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		ri, err := c.objectResource(ctx, obj)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		_, err = ri.Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{FieldManager: FieldManager, Force: true})
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+// Delete removes the objects in the manifests from the namespaces mapped for
+// ctx and waits until they are gone, so the manifests can be applied again
+// right away. Namespaces are kept because other questions may have resources
+// in them, and objects that are already gone are ignored.
+func (c *Clients) Delete(ctx context.Context, manifests []byte) error {
+	objects, err := DecodeManifests(manifests)
+	if err != nil {
+		return err
+	}
+
+	type deletedObject struct {
+		obj *unstructured.Unstructured
+		ri  dynamic.ResourceInterface
+	}
+	var deleted []deletedObject
+	for _, obj := range objects {
+		if obj.GetKind() == "Namespace" {
+			continue
+		}
+		ri, err := c.objectResource(ctx, obj)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		err = ri.Delete(ctx, obj.GetName(), metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("%s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		deleted = append(deleted, deletedObject{obj, ri})
+	}
+
+	for _, d := range deleted {
+		obj, ri := d.obj, d.ri
+		err := wait.PollUntilContextTimeout(ctx, time.Second, deleteTimeout, true, func(ctx context.Context) (bool, error) {
+			_, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return true, nil
+			}
+			return false, err
+		})
+		if err != nil {
+			return fmt.Errorf("waiting for %s %s to be deleted: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
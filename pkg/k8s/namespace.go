@@ -0,0 +1,73 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrReservedNamespace is returned for a prefix that would map exercise
+// namespaces to those of Kubernetes, e.g. kube with public to kube-public.
+var ErrReservedNamespace = errors.New("namespaces starting with kube- are reserved for Kubernetes")
+
+// refusedNamespace is what Namespace maps to instead of a reserved
+// namespace. It is not a valid namespace name, so requests for it fail.
+const refusedNamespace = "reserved.invalid"
+
+type namespacePrefixKey struct{}
+
+// WithNamespacePrefix returns a copy of ctx in which every exercise
+// namespace is mapped to one starting with prefix, so several learners can
+// share a cluster without touching each other's resources.
+func WithNamespacePrefix(ctx context.Context, prefix string) context.Context {
+	return context.WithValue(ctx, namespacePrefixKey{}, prefix)
+}
+
+// NamespacePrefix returns the namespace prefix carried by ctx, if any.
+func NamespacePrefix(ctx context.Context) string {
+	prefix, _ := ctx.Value(namespacePrefixKey{}).(string)
+	return prefix
+}
+
+// CheckNamespacePrefix fails for a prefix that would map exercise
+// namespaces to reserved ones.
+func CheckNamespacePrefix(prefix string) error {
+	if prefix != "" && reserved(prefix+"-") {
+		return ErrReservedNamespace
+	}
+	return nil
+}
+
+func reserved(name string) bool {
+	return strings.HasPrefix(name, "kube-")
+}
+
+// Namespace maps the namespace named in a question, e.g. "colors", to the
+// namespace used in ctx, e.g. "alice-colors". Without a prefix the name is
+// returned unchanged. A mapping to a namespace of Kubernetes, kube-*, is
+// refused with a name no request can succeed for.
+func Namespace(ctx context.Context, name string) string {
+	prefix := NamespacePrefix(ctx)
+	if prefix == "" || name == "" {
+		return name
+	}
+	mapped := prefix + "-" + name
+	if reserved(mapped) {
+		return refusedNamespace
+	}
+	return mapped
+}
+
+// EnsureNamespace creates the namespace mapped from name unless it exists.
+func (c *Clients) EnsureNamespace(ctx context.Context, name string) error {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: Namespace(ctx, name)}}
+	_, err := c.Clientset().CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
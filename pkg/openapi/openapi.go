@@ -0,0 +1,181 @@
+// Package openapi generates OpenAPI 3 documents from the routes of an HTTP
+// API, with the schemas of the request and response bodies derived from
+// their Go types.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is the version of the OpenAPI specification of the documents.
+const Version = "3.0.3"
+
+// Route describes an operation of the API.
+type Route struct {
+	// ID names the operation, e.g. listQuestions.
+	ID     string
+	Method string
+	// Path is relative to the server URL, with path parameters in braces.
+	// Parameters may carry a gorilla/mux pattern, e.g. /questions/{id:[0-9]+};
+	// those that only match digits are documented as integers.
+	Path        string
+	Summary     string
+	Description string
+	Tag         string
+	// Params are the query parameters and descriptions of the path
+	// parameters.
+	Params []Parameter
+	// Request is a value of the type of the JSON request body, or nil.
+	Request any
+	// Response is a value of the type of the JSON response body, or nil
+	// when the response has no JSON body.
+	Response any
+	// ContentTypes are other media types of the response, e.g.
+	// text/event-stream or those of the result formats.
+	ContentTypes []string
+	// Status is the status of a successful response; 200 when zero.
+	Status int
+	// Errors are the statuses of the error responses besides 401.
+	Errors []int
+	// Public routes do not require authentication.
+	Public bool
+}
+
+// Generator builds the document of an API.
+type Generator struct {
+	Info    Info
+	Servers []Server
+	// Security are the schemes that authenticate every route but the
+	// public ones; any of them is accepted.
+	Security map[string]SecurityScheme
+	// Error is a value of the type of the error bodies.
+	Error any
+}
+
+// Generate returns the document of the routes.
+func (g Generator) Generate(routes []Route) *Document {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    g.Info,
+		Servers: g.Servers,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas:         map[string]*Schema{},
+			SecuritySchemes: g.Security,
+		},
+	}
+	for name := range g.Security {
+		doc.Security = append(doc.Security, SecurityRequirement{name: {}})
+	}
+	sort.Slice(doc.Security, func(i, j int) bool {
+		return firstKey(doc.Security[i]) < firstKey(doc.Security[j])
+	})
+
+	schemas := newSchemas(doc.Components.Schemas)
+	var errorSchema *Schema
+	if g.Error != nil {
+		errorSchema = schemas.of(reflect.TypeOf(g.Error))
+	}
+
+	for _, route := range routes {
+		path, params := pathParams(route.Path)
+		op := &Operation{
+			OperationID: route.ID,
+			Summary:     route.Summary,
+			Description: route.Description,
+			Responses:   map[string]Response{},
+		}
+		if route.Tag != "" {
+			op.Tags = []string{route.Tag}
+		}
+		for _, p := range params {
+			for _, described := range route.Params {
+				if described.In == "path" && described.Name == p.Name {
+					p.Description = described.Description
+				}
+			}
+			op.Parameters = append(op.Parameters, p)
+		}
+		for _, p := range route.Params {
+			if p.In != "path" {
+				op.Parameters = append(op.Parameters, p)
+			}
+		}
+		if route.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: schemas.of(reflect.TypeOf(route.Request))}},
+			}
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		ok := Response{Description: http.StatusText(status)}
+		if route.Response != nil || len(route.ContentTypes) > 0 {
+			ok.Content = map[string]MediaType{}
+		}
+		if route.Response != nil {
+			ok.Content["application/json"] = MediaType{Schema: schemas.of(reflect.TypeOf(route.Response))}
+		}
+		for _, contentType := range route.ContentTypes {
+			ok.Content[contentType] = MediaType{Schema: &Schema{Type: "string"}}
+		}
+		op.Responses[strconv.Itoa(status)] = ok
+
+		errorStatuses := route.Errors
+		if route.Public {
+			op.Security = &[]SecurityRequirement{}
+		} else if len(g.Security) > 0 {
+			errorStatuses = append([]int{http.StatusUnauthorized}, errorStatuses...)
+		}
+		for _, status := range errorStatuses {
+			response := Response{Description: http.StatusText(status)}
+			if errorSchema != nil {
+				response.Content = map[string]MediaType{"application/json": {Schema: errorSchema}}
+			}
+			op.Responses[strconv.Itoa(status)] = response
+		}
+
+		item := doc.Paths[path]
+		if item == nil {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+	return doc
+}
+
+// pathParam matches the parameters of a route path and their optional
+// gorilla/mux patterns.
+var pathParam = regexp.MustCompile(`\{([^{}:]+)(?::([^{}]*(?:\{[^{}]*\}[^{}]*)*))?\}`)
+
+// pathParams returns path without the patterns of its parameters, and the
+// parameters.
+func pathParams(path string) (string, []Parameter) {
+	var params []Parameter
+	path = pathParam.ReplaceAllStringFunc(path, func(match string) string {
+		m := pathParam.FindStringSubmatch(match)
+		schema := &Schema{Type: "string"}
+		if m[2] == "[0-9]+" || m[2] == `\d+` {
+			schema = &Schema{Type: "integer"}
+		}
+		params = append(params, Parameter{Name: m[1], In: "path", Required: true, Schema: schema})
+		return "{" + m[1] + "}"
+	})
+	return path, params
+}
+
+func firstKey(requirement SecurityRequirement) string {
+	for name := range requirement {
+		return name
+	}
+	return ""
+}
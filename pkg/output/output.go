@@ -0,0 +1,317 @@
+// Package output writes grading results in formats for people and for
+// tools such as CI systems: a table, Markdown, JSON, JUnit XML and TAP.
+package output
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"kubelearn/pkg/scoring"
+	"kubelearn/pkg/utils"
+
+	"github.com/fatih/color"
+)
+
+// Formatter writes grading results. report is the score of the results and
+// may be nil.
+type Formatter interface {
+	// ContentType is the media type of the output.
+	ContentType() string
+	Format(w io.Writer, results []utils.Result, report *scoring.Report) error
+}
+
+var formatters = map[string]Formatter{
+	"table":    table{},
+	"markdown": markdown{},
+	"json":     jsonFormatter{},
+	"junit":    junit{},
+	"tap":      tap{},
+}
+
+// mediaTypes maps the media types of content negotiation to formats, in
+// order of preference.
+var mediaTypes = []struct {
+	mediaType string
+	format    string
+}{
+	{"application/json", "json"},
+	{"application/junit+xml", "junit"},
+	{"application/xml", "junit"},
+	{"text/xml", "junit"},
+	{"text/x-tap", "tap"},
+	{"text/markdown", "markdown"},
+	{"text/plain", "table"},
+}
+
+// Names returns the names of the formats, sorted.
+func Names() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the formatter of the named format. colored colors the table
+// for a terminal when color output is enabled.
+func Get(name string, colored bool) (Formatter, error) {
+	f, ok := formatters[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q; use one of %s", name, strings.Join(Names(), ", "))
+	}
+	if _, ok := f.(table); ok {
+		return table{colored: colored}, nil
+	}
+	return f, nil
+}
+
+// Negotiate picks the format for an Accept header, preferring the media
+// types with the highest quality. It returns false when the header accepts
+// none of them. An empty header accepts JSON.
+func Negotiate(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return "json", true
+	}
+	var best string
+	bestQuality := 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= bestQuality {
+			continue
+		}
+		for _, m := range mediaTypes {
+			if matchMediaType(mediaType, m.mediaType) {
+				best, bestQuality = m.format, quality
+				break
+			}
+		}
+	}
+	return best, best != ""
+}
+
+// matchMediaType reports whether a media range of an Accept header, such as
+// text/*, includes mediaType.
+func matchMediaType(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// summary describes the score of a report in one line, e.g.
+// "Score: 72.5% (pass mark 66%) PASSED".
+// The verdict is colored when colored is set and color output is enabled.
+func summary(report *scoring.Report, colored bool) string {
+	verdict, paint := "PASSED", color.GreenString
+	if !report.Passed {
+		verdict, paint = "FAILED", color.RedString
+	}
+	if colored {
+		verdict = paint(verdict)
+	}
+	return fmt.Sprintf("Score: %.1f%% (pass mark %.0f%%) %s", report.Score, report.PassThreshold, verdict)
+}
+
+// failureMessage summarizes why a result failed.
+func failureMessage(result utils.Result) string {
+	failed := len(result.Failed())
+	if failed == 0 {
+		return "no criteria were graded"
+	}
+	return fmt.Sprintf("%d of %d criteria failed", failed, len(result.Criteria))
+}
+
+type jsonFormatter struct{}
+
+func (jsonFormatter) ContentType() string { return "application/json" }
+
+func (jsonFormatter) Format(w io.Writer, results []utils.Result, report *scoring.Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Report  *scoring.Report `json:"report,omitempty"`
+		Results []utils.Result  `json:"results"`
+	}{report, results})
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Cases      []junitCase      `xml:"testcase"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Details string `xml:",chardata"`
+}
+
+// junit writes a test suite with a test case per question, classified by
+// difficulty. Failed criteria are the details of the failure.
+type junit struct{}
+
+func (junit) ContentType() string { return "application/xml" }
+
+func (junit) Format(w io.Writer, results []utils.Result, report *scoring.Report) error {
+	suite := junitSuite{Name: "kubelearn", Tests: len(results)}
+	if report != nil {
+		suite.Properties = &junitProperties{[]junitProperty{
+			{Name: "score", Value: strconv.FormatFloat(report.Score, 'f', 1, 64)},
+			{Name: "passThreshold", Value: strconv.FormatFloat(report.PassThreshold, 'f', -1, 64)},
+			{Name: "passed", Value: strconv.FormatBool(report.Passed)},
+		}}
+	}
+	for _, result := range results {
+		c := junitCase{Name: result.TestName, ClassName: "kubelearn." + strings.ToLower(result.Difficulty)}
+		if !result.Passed {
+			suite.Failures++
+			c.Failure = &junitFailure{
+				Message: failureMessage(result),
+				Type:    "CriteriaFailed",
+				Details: strings.Join(result.FailureDetails(), "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// tap writes the Test Anything Protocol, version 13, with the failed
+// criteria of a question in its YAML diagnostics.
+type tap struct{}
+
+func (tap) ContentType() string { return "text/x-tap; charset=utf-8" }
+
+func (tap) Format(w io.Writer, results []utils.Result, report *scoring.Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "TAP version 13\n1..%d\n", len(results))
+	for i, result := range results {
+		status := "ok"
+		if !result.Passed {
+			status = "not ok"
+		}
+		fmt.Fprintf(&b, "%s %d - %s\n", status, i+1, strings.ReplaceAll(result.TestName, "#", "\\#"))
+		if result.Passed {
+			continue
+		}
+		b.WriteString("  ---\n")
+		fmt.Fprintf(&b, "  message: %s\n", strconv.Quote(failureMessage(result)))
+		fmt.Fprintf(&b, "  severity: fail\n  difficulty: %s\n", result.Difficulty)
+		if failed := result.Failed(); len(failed) > 0 {
+			b.WriteString("  failures:\n")
+			for _, c := range failed {
+				fmt.Fprintf(&b, "    - name: %s\n      expected: %s\n      observed: %s\n",
+					strconv.Quote(c.Name), strconv.Quote(c.Expected), strconv.Quote(c.Observed))
+			}
+		}
+		b.WriteString("  ...\n")
+	}
+	if report != nil {
+		fmt.Fprintf(&b, "# %s\n", summary(report, false))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdown writes a table for pull requests, issues and chat.
+type markdown struct{}
+
+func (markdown) ContentType() string { return "text/markdown; charset=utf-8" }
+
+func (markdown) Format(w io.Writer, results []utils.Result, report *scoring.Report) error {
+	var b strings.Builder
+	b.WriteString("| Question | Result | Difficulty | Details |\n| --- | --- | --- | --- |\n")
+	for _, result := range results {
+		status := "✅ Pass"
+		details := ""
+		if !result.Passed {
+			status = "🆘 Fail"
+			lines := result.FailureDetails()
+			if len(lines) == 0 {
+				lines = []string{failureMessage(result)}
+			}
+			for i, line := range lines {
+				lines[i] = escapeMarkdown(line)
+			}
+			details = strings.Join(lines, "<br>")
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", escapeMarkdown(result.TestName), status, result.Difficulty, details)
+	}
+	if report != nil {
+		fmt.Fprintf(&b, "\n**%s**\n", summary(report, false))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeMarkdown keeps text from breaking out of a table cell or being read
+// as HTML.
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", "<br>", "&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// table writes the results table of the terminal.
+type table struct {
+	// colored colors pass and fail when color output is enabled.
+	colored bool
+}
+
+func (table) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (t table) Format(w io.Writer, results []utils.Result, report *scoring.Report) error {
+	utils.WriteResultsTable(w, results, t.colored)
+	if report == nil {
+		return nil
+	}
+	_, err := fmt.Fprintln(w, summary(report, t.colored))
+	return err
+}
@@ -0,0 +1,167 @@
+package output
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"kubelearn/pkg/scoring"
+	"kubelearn/pkg/utils"
+)
+
+var results = []utils.Result{
+	{ID: 1, TestName: "Question 1 - Create a pod", Passed: true, Difficulty: "Easy",
+		Criteria: []utils.Criterion{{Name: "image", Expected: "nginx", Observed: "nginx", Passed: true}}},
+	{ID: 2, TestName: "Question 2 - Fix pod #2", Difficulty: "Hard",
+		Criteria: []utils.Criterion{
+			{Name: "image", Expected: "nginx:alpine", Observed: "nginx", Passed: false},
+			{Name: "replicas", Expected: "3", Observed: "3", Passed: true},
+		}},
+	{ID: 3, TestName: "Question 3 - Create a namespace", Difficulty: "Easy"},
+}
+
+var report = &scoring.Report{Score: 42.857, PassThreshold: 66}
+
+func format(t *testing.T, name string, report *scoring.Report) string {
+	t.Helper()
+	f, err := Get(name, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := f.Format(&b, results, report); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestTAP(t *testing.T) {
+	want := `TAP version 13
+1..3
+ok 1 - Question 1 - Create a pod
+not ok 2 - Question 2 - Fix pod \#2
+  ---
+  message: "1 of 2 criteria failed"
+  severity: fail
+  difficulty: Hard
+  failures:
+    - name: "image"
+      expected: "nginx:alpine"
+      observed: "nginx"
+  ...
+not ok 3 - Question 3 - Create a namespace
+  ---
+  message: "no criteria were graded"
+  severity: fail
+  difficulty: Easy
+  ...
+# Score: 42.9% (pass mark 66%) FAILED
+`
+	if got := format(t, "tap", report); got != want {
+		t.Errorf("TAP output\n%s\nwant\n%s", got, want)
+	}
+	if got := format(t, "tap", nil); strings.Contains(got, "# Score") {
+		t.Errorf("TAP output without a report has a score:\n%s", got)
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	out := format(t, "junit", report)
+	if !strings.HasPrefix(out, xml.Header) {
+		t.Errorf("JUnit output lacks the XML header:\n%s", out)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal([]byte(out), &suites); err != nil {
+		t.Fatal(err)
+	}
+	if len(suites.Suites) != 1 {
+		t.Fatalf("%d test suites, want 1", len(suites.Suites))
+	}
+	suite := suites.Suites[0]
+	if suite.Tests != 3 || suite.Failures != 2 {
+		t.Errorf("tests %d, failures %d, want 3, 2", suite.Tests, suite.Failures)
+	}
+	if suite.Properties == nil {
+		t.Fatal("no properties")
+	}
+	wantProperties := map[string]string{"score": "42.9", "passThreshold": "66", "passed": "false"}
+	for _, p := range suite.Properties.Properties {
+		if wantProperties[p.Name] != p.Value {
+			t.Errorf("property %s = %q, want %q", p.Name, p.Value, wantProperties[p.Name])
+		}
+		delete(wantProperties, p.Name)
+	}
+	if len(wantProperties) > 0 {
+		t.Errorf("missing properties %v", wantProperties)
+	}
+
+	cases := suite.Cases
+	if len(cases) != 3 {
+		t.Fatalf("%d test cases, want 3", len(cases))
+	}
+	if cases[0].Failure != nil || cases[0].ClassName != "kubelearn.easy" {
+		t.Errorf("case 1 = %+v, want a passed kubelearn.easy case", cases[0])
+	}
+	failure := cases[1].Failure
+	if failure == nil || cases[1].ClassName != "kubelearn.hard" {
+		t.Fatalf("case 2 = %+v, want a failed kubelearn.hard case", cases[1])
+	}
+	if failure.Message != "1 of 2 criteria failed" || failure.Type != "CriteriaFailed" || failure.Details != "image: expected nginx:alpine, got nginx" {
+		t.Errorf("failure = %+v", failure)
+	}
+	if cases[2].Failure == nil || cases[2].Failure.Message != "no criteria were graded" {
+		t.Errorf("case 3 = %+v, want a failure without criteria", cases[2])
+	}
+
+	if out := format(t, "junit", nil); strings.Contains(out, "<properties") {
+		t.Errorf("JUnit output without a report has properties:\n%s", out)
+	}
+}
+
+func TestGet(t *testing.T) {
+	for _, name := range Names() {
+		if _, err := Get(name, false); err != nil {
+			t.Errorf("Get(%q): %v", name, err)
+		}
+	}
+	if _, err := Get("yaml", false); err == nil {
+		t.Error("Get(yaml) succeeded")
+	}
+	if f, _ := Get("table", true); !f.(table).colored {
+		t.Error("Get(table, true) is not colored")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"", "json", true},
+		{"application/json", "json", true},
+		{"application/xml", "junit", true},
+		{"application/junit+xml", "junit", true},
+		{"text/xml", "junit", true},
+		{"text/x-tap", "tap", true},
+		{"text/markdown; charset=utf-8", "markdown", true},
+		{"text/plain", "table", true},
+		{"*/*", "json", true},
+		{"text/*", "junit", true},
+		{"text/html, text/x-tap;q=0.5, application/xml;q=0.9", "junit", true},
+		{"text/x-tap;q=0.9, text/markdown", "markdown", true},
+		{"text/markdown;q=0.5, text/x-tap;q=0.5", "markdown", true},
+		{"text/x-tap;q=abc, text/plain;q=0.1", "table", true},
+		{"application/json;q=0", "", false},
+		{"text/html", "", false},
+		{"image/png, invalid;;", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Negotiate(tt.accept)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Negotiate(%q) = %q, %v, want %q, %v", tt.accept, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+// Package policy decides which command lines learners may run in their
+// terminals.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"kubelearn/pkg/command"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Actions of a rule.
+const (
+	Allow = "allow"
+	Deny  = "deny"
+)
+
+// Builtins is the program name that matches the shell builtins in a rule.
+const Builtins = "@builtins"
+
+// builtins are the bash builtins and keywords a policy may allow with
+// Builtins. Those that run code the policy cannot see are left out: source
+// and . run files, trap, fc and bind run commands later, and a bind of
+// Enter would skip the check altogether. The commands that eval, builtin
+// and command run are checked instead, as are the values of aliases.
+var builtins = map[string]bool{
+	":": true, "[": true, "alias": true, "bg": true, "break": true,
+	"case": true, "cd": true, "clear": true, "compgen": true,
+	"complete": true, "continue": true, "declare": true, "dirs": true,
+	"echo": true, "exit": true, "export": true, "false": true, "fg": true,
+	"for": true, "function": true, "getopts": true, "hash": true,
+	"help": true, "history": true, "if": true, "jobs": true, "let": true,
+	"local": true, "logout": true, "popd": true, "printf": true,
+	"pushd": true, "pwd": true, "read": true, "readonly": true,
+	"return": true, "set": true, "shift": true, "shopt": true, "test": true,
+	"times": true, "true": true, "type": true, "typeset": true,
+	"ulimit": true, "umask": true, "unalias": true, "unset": true,
+	"until": true, "wait": true, "while": true, "}": true, "done": true,
+	"esac": true, "fi": true,
+}
+
+// Rule matches simple commands of a command line. Every field that is set
+// must match; a rule without fields matches every command. Programs,
+// names and namespaces are shell patterns, e.g. kube-*.
+type Rule struct {
+	// Name identifies the rule in violations.
+	Name string `json:"name,omitempty"`
+	// Action is allow or deny.
+	Action string `json:"action"`
+	// Programs match the program, e.g. kubectl, or Builtins.
+	Programs []string `json:"programs,omitempty"`
+	// Verbs match the kubectl verb, e.g. delete.
+	Verbs []string `json:"verbs,omitempty"`
+	// Kinds match the kubectl resource type, given by any of its names,
+	// e.g. ns for namespaces.
+	Kinds []string `json:"kinds,omitempty"`
+	// Names match the names of the kubectl resources. A deny rule matches
+	// when any of them matches, an allow rule only when all of them do.
+	Names []string `json:"names,omitempty"`
+	// Namespaces match the namespace given to kubectl, * for
+	// --all-namespaces or the empty string for the default one.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Flags must all be given to kubectl, by their long names; name=value
+	// also requires the value, e.g. grace-period=0.
+	Flags []string `json:"flags,omitempty"`
+	// Message is shown to the learner instead of the default one when the
+	// rule denies a command.
+	Message string `json:"message,omitempty"`
+}
+
+// Policy is an ordered list of rules. Each simple command of a command line
+// is decided by the first rule that matches it, or by Default when none
+// does; the line runs only if all its commands are allowed.
+//
+// The policy is checked by the shell before it runs a line, so learners
+// can work around it; it keeps them to the tools of the exam and guards
+// against mistakes, while the sandbox is what protects the cluster.
+type Policy struct {
+	// Default is allow or deny. It is allow when empty.
+	Default string `json:"default,omitempty"`
+	Rules   []Rule `json:"rules"`
+}
+
+// Violation is a command line the policy denies.
+type Violation struct {
+	// Line is the denied command line.
+	Line string
+	// Program is the program of the denied command.
+	Program string
+	// Rule is the name of the rule that denied it, or empty for the
+	// default action.
+	Rule    string
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// Load reads a policy from a YAML or JSON file.
+func Load(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, err
+	}
+	var p Policy
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return Policy{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return Policy{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Validate reports the first problem with the policy.
+func (p Policy) Validate() error {
+	if p.Default != "" && p.Default != Allow && p.Default != Deny {
+		return fmt.Errorf("default must be %s or %s", Allow, Deny)
+	}
+	for i, r := range p.Rules {
+		if r.Action != Allow && r.Action != Deny {
+			return fmt.Errorf("rule %d: action must be %s or %s", i, Allow, Deny)
+		}
+		for _, pattern := range append(append(append([]string{}, r.Programs...), r.Names...), r.Namespaces...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("rule %d: invalid pattern %q", i, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// Check returns a *Violation if the policy denies a command of line, and
+// nil otherwise.
+func (p Policy) Check(line string) error {
+	for _, inv := range withAliases(command.Parse(line)) {
+		rule, ok := p.decide(inv)
+		action := p.Default
+		if ok {
+			action = rule.Action
+		}
+		if action != Deny {
+			continue
+		}
+		v := &Violation{Line: line, Program: inv.Program, Rule: rule.Name, Message: rule.Message}
+		if v.Message == "" {
+			v.Message = fmt.Sprintf("%s is not allowed in this terminal", describe(inv))
+		}
+		return v
+	}
+	return nil
+}
+
+// withAliases adds the commands that the aliases defined by invocations
+// stand for, e.g. kubectl for alias k=kubectl, since they run when the
+// alias is used.
+func withAliases(invocations []command.Invocation) []command.Invocation {
+	out := invocations
+	for _, inv := range invocations {
+		if inv.Program != "alias" {
+			continue
+		}
+		for _, arg := range inv.Args {
+			if _, value, ok := strings.Cut(arg, "="); ok {
+				out = append(out, command.Parse(value)...)
+			}
+		}
+	}
+	return out
+}
+
+// decide returns the first rule that matches inv.
+func (p Policy) decide(inv command.Invocation) (Rule, bool) {
+	for _, r := range p.Rules {
+		if r.matches(inv) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+func (r Rule) matches(inv command.Invocation) bool {
+	if len(r.Programs) > 0 && !matchProgram(r.Programs, inv.Program) {
+		return false
+	}
+	if len(r.Verbs) == 0 && len(r.Kinds) == 0 && len(r.Names) == 0 && len(r.Namespaces) == 0 && len(r.Flags) == 0 {
+		return true
+	}
+	k := inv.Kubectl
+	if k == nil {
+		return false
+	}
+	if len(r.Verbs) > 0 && !contains(r.Verbs, k.Verb) {
+		return false
+	}
+	if len(r.Kinds) > 0 && !matchKinds(r.Kinds, k.Kind) {
+		return false
+	}
+	if len(r.Names) > 0 && !r.matchNames(k.Names) {
+		return false
+	}
+	if len(r.Namespaces) > 0 && !matchAny(r.Namespaces, k.Namespace) {
+		return false
+	}
+	for _, flag := range r.Flags {
+		name, value, hasValue := strings.Cut(flag, "=")
+		given, ok := k.Flags[name]
+		if !ok || hasValue && given != value {
+			return false
+		}
+	}
+	return true
+}
+
+// matchNames reports whether the resource names match the rule: any of
+// them for deny rules, so that kubectl delete ns default kube-system is
+// denied by kube-*, and all of them for allow rules. A command without
+// names is matched by its empty name.
+func (r Rule) matchNames(names []string) bool {
+	if len(names) == 0 {
+		return matchAny(r.Names, "")
+	}
+	for _, name := range names {
+		if matchAny(r.Names, name) == (r.Action == Deny) {
+			return r.Action == Deny
+		}
+	}
+	return r.Action != Deny
+}
+
+func matchProgram(patterns []string, program string) bool {
+	for _, pattern := range patterns {
+		if pattern == Builtins && builtins[program] {
+			return true
+		}
+	}
+	return matchAny(patterns, program)
+}
+
+// matchKinds reports whether any of the comma-separated kinds is one of
+// the rule's kinds, ignoring API groups.
+func matchKinds(ruleKinds []string, kinds string) bool {
+	for _, kind := range strings.Split(kinds, ",") {
+		kind, _, _ = strings.Cut(kind, ".")
+		for _, ruleKind := range ruleKinds {
+			if ruleKind, _, _ = strings.Cut(command.KindName(ruleKind), "."); ruleKind == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// describe names the denied command for the default message, e.g.
+// "kubectl delete namespaces".
+func describe(inv command.Invocation) string {
+	k := inv.Kubectl
+	if k == nil || k.Verb == "" {
+		return inv.Program
+	}
+	words := []string{inv.Program, k.Verb}
+	if k.Subcommand != "" {
+		words = append(words, k.Subcommand)
+	}
+	if k.Kind != "" {
+		words = append(words, k.Kind)
+	}
+	words = append(words, k.Names...)
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,75 @@
+package policy
+
+import "testing"
+
+func TestCheckNames(t *testing.T) {
+	p := Policy{
+		Default: Deny,
+		Rules: []Rule{
+			{Name: "protect-system-namespaces", Action: Deny, Programs: []string{"kubectl"}, Verbs: []string{"delete"}, Kinds: []string{"ns"}, Names: []string{"kube-*"}},
+			{Name: "own-pods", Action: Allow, Programs: []string{"kubectl"}, Verbs: []string{"delete"}, Kinds: []string{"pods"}, Names: []string{"web-*"}},
+			{Action: Allow, Programs: []string{"kubectl"}, Verbs: []string{"get"}},
+		},
+	}
+	tests := []struct {
+		line string
+		rule string
+		deny bool
+	}{
+		{"kubectl delete ns kube-system", "protect-system-namespaces", true},
+		{"kubectl delete ns default kube-system", "protect-system-namespaces", true},
+		{"kubectl delete namespace/default namespace/kube-public", "protect-system-namespaces", true},
+		{"kubectl delete ns default", "", true},
+		{"kubectl delete pods web-1 web-2", "", false},
+		{"kubectl delete pods web-1 db-1", "", true},
+		{"kubectl delete pod/web-1", "", false},
+		{"kubectl get ns kube-system", "", false},
+	}
+	for _, tt := range tests {
+		err := p.Check(tt.line)
+		if (err != nil) != tt.deny {
+			t.Errorf("Check(%q) = %v, want denied %v", tt.line, err, tt.deny)
+			continue
+		}
+		if v, ok := err.(*Violation); ok && v.Rule != tt.rule {
+			t.Errorf("Check(%q) denied by rule %q, want %q", tt.line, v.Rule, tt.rule)
+		}
+	}
+}
+
+func TestCheckBuiltins(t *testing.T) {
+	p := Policy{
+		Default: Deny,
+		Rules: []Rule{
+			{Name: "protect-system-namespaces", Action: Deny, Programs: []string{"kubectl"}, Verbs: []string{"delete"}, Kinds: []string{"ns"}, Names: []string{"kube-*"}},
+			{Action: Allow, Programs: []string{"kubectl", "k", "helm", "vi", Builtins}},
+		},
+	}
+	tests := []struct {
+		line string
+		deny bool
+	}{
+		{"cd /tmp && echo ok", false},
+		{"kubectl get pods", false},
+		{`eval "kubectl get pods"`, false},
+		{`eval "rm -rf ~"`, true},
+		{`builtin eval "rm -rf ~"`, true},
+		{"builtin cd /tmp", false},
+		{"source ./setup.sh", true},
+		{". ./setup.sh", true},
+		{`bind '"\C-m": accept-line'`, true},
+		{"trap 'rm -rf ~' EXIT", true},
+		{"command -v kubectl", true},
+		{"command kubectl get pods", false},
+		{`echo "$(kubectl delete ns kube-system)"`, true},
+		{"echo `rm -rf ~`", true},
+		{"echo $(kubectl get ns)", false},
+		{"alias k=kubectl", false},
+		{"alias ls='rm -rf ~'", true},
+	}
+	for _, tt := range tests {
+		if err := p.Check(tt.line); (err != nil) != tt.deny {
+			t.Errorf("Check(%q) = %v, want denied %v", tt.line, err, tt.deny)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package provision
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// StepPending is the state of a job that has not run yet.
+const StepPending Step = "pending"
+
+// Status is a snapshot of a setup job.
+type Status struct {
+	State      Step      `json:"state"`
+	Running    bool      `json:"running"`
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"startedAt,omitempty"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+	Logs       []Event   `json:"logs"`
+}
+
+// Job runs the provisioner in the background, keeps its state and logs, and
+// fans the progress events out to subscribers. Only one run is active at a
+// time.
+type Job struct {
+	opts Options
+
+	mu          sync.Mutex
+	status      Status
+	subscribers map[chan Event]struct{}
+}
+
+// NewJob returns an idle job that provisions with the given options. The
+// Reporter of opts, if any, still receives every event.
+func NewJob(opts Options) *Job {
+	return &Job{
+		opts:        opts,
+		status:      Status{State: StepPending},
+		subscribers: map[chan Event]struct{}{},
+	}
+}
+
+// Start begins a new run unless one is already in progress. It reports
+// whether a run was started.
+func (j *Job) Start() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.status.Running {
+		return false
+	}
+	j.status = Status{State: StepPending, Running: true, StartedAt: time.Now()}
+
+	opts := j.opts
+	reporter := opts.Reporter
+	opts.Reporter = func(event Event) {
+		if reporter != nil {
+			reporter(event)
+		}
+		j.record(event)
+	}
+	go func() {
+		err := New(opts).Run(context.Background())
+		j.finish(err)
+	}()
+	return true
+}
+
+func (j *Job) record(event Event) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status.State = event.Step
+	j.status.Logs = append(j.status.Logs, event)
+	for ch := range j.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// A subscriber that cannot keep up misses the event rather
+			// than stalling provisioning; the full log stays in Status.
+		}
+	}
+}
+
+func (j *Job) finish(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status.Running = false
+	j.status.FinishedAt = time.Now()
+	if err != nil {
+		j.status.Error = err.Error()
+	}
+	for ch := range j.subscribers {
+		close(ch)
+		delete(j.subscribers, ch)
+	}
+}
+
+// Status returns a snapshot of the job.
+func (j *Job) Status() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	status := j.status
+	status.Logs = append([]Event(nil), j.status.Logs...)
+	return status
+}
+
+// Subscribe returns the events logged so far and a channel receiving the
+// next ones. The channel is closed when the run finishes, right away if no
+// run is in progress. Call cancel to stop receiving events early.
+func (j *Job) Subscribe() (past []Event, events <-chan Event, cancel func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	ch := make(chan Event, 64)
+	past = append([]Event(nil), j.status.Logs...)
+	if !j.status.Running {
+		close(ch)
+		return past, ch, func() {}
+	}
+
+	j.subscribers[ch] = struct{}{}
+	cancel = func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if _, ok := j.subscribers[ch]; ok {
+			delete(j.subscribers, ch)
+			close(ch)
+		}
+	}
+	return past, ch, cancel
+}
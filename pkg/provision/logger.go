@@ -0,0 +1,49 @@
+package provision
+
+import "sigs.k8s.io/kind/pkg/log"
+
+// logger forwards the user facing messages of kind as progress events.
+type logger struct {
+	report func(step Step, format string, args ...interface{})
+}
+
+func (l *logger) Warn(message string) {
+	l.report(StepCreatingCluster, "%s", message)
+}
+
+func (l *logger) Warnf(format string, args ...interface{}) {
+	l.report(StepCreatingCluster, format, args...)
+}
+
+func (l *logger) Error(message string) {
+	l.report(StepCreatingCluster, "%s", message)
+}
+
+func (l *logger) Errorf(format string, args ...interface{}) {
+	l.report(StepCreatingCluster, format, args...)
+}
+
+func (l *logger) V(level log.Level) log.InfoLogger {
+	return infoLogger{logger: l, enabled: level == 0}
+}
+
+type infoLogger struct {
+	logger  *logger
+	enabled bool
+}
+
+func (i infoLogger) Info(message string) {
+	if i.enabled {
+		i.logger.report(StepCreatingCluster, "%s", message)
+	}
+}
+
+func (i infoLogger) Infof(format string, args ...interface{}) {
+	if i.enabled {
+		i.logger.report(StepCreatingCluster, format, args...)
+	}
+}
+
+func (i infoLogger) Enabled() bool {
+	return i.enabled
+}
@@ -0,0 +1,168 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
+	"kubelearn/pkg/utils"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+	"sigs.k8s.io/kind/pkg/cluster"
+)
+
+const (
+	// DefaultClusterName is the kind cluster kubelearn creates and reuses.
+	DefaultClusterName = "kubelearn"
+	// DefaultNodeImage matches the node image of the Terraform configuration.
+	DefaultNodeImage = "kindest/node:v1.27.1"
+)
+
+// Step is a stage of provisioning.
+type Step string
+
+const (
+	StepCreatingCluster   Step = "creating-cluster"
+	StepApplyingManifests Step = "applying-manifests"
+	StepReady             Step = "ready"
+	StepFailed            Step = "failed"
+)
+
+// Event reports the progress of provisioning.
+type Event struct {
+	Step    Step      `json:"step"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Reporter receives progress events as they happen.
+type Reporter func(Event)
+
+// Options configures a Provisioner. Zero values fall back to the defaults.
+type Options struct {
+	ClusterName string
+	NodeImage   string
+	// KubeconfigPath is where kind exports the cluster credentials. Empty
+	// means the kind default, which honours KUBECONFIG.
+	KubeconfigPath string
+	// WaitForReady bounds how long cluster creation waits for the nodes.
+	WaitForReady time.Duration
+	Reporter     Reporter
+}
+
+// Provisioner creates or reuses a kind cluster and applies the scenario of
+// every registered question to it.
+type Provisioner struct {
+	opts     Options
+	provider *cluster.Provider
+}
+
+// New returns a Provisioner using the kind library with the given options.
+func New(opts Options) *Provisioner {
+	if opts.ClusterName == "" {
+		opts.ClusterName = DefaultClusterName
+	}
+	if opts.NodeImage == "" {
+		opts.NodeImage = DefaultNodeImage
+	}
+	if opts.WaitForReady == 0 {
+		opts.WaitForReady = 5 * time.Minute
+	}
+	if opts.Reporter == nil {
+		opts.Reporter = func(Event) {}
+	}
+
+	p := &Provisioner{opts: opts}
+	p.provider = cluster.NewProvider(cluster.ProviderWithLogger(&logger{report: p.report}))
+	return p
+}
+
+func (p *Provisioner) report(step Step, format string, args ...interface{}) {
+	p.opts.Reporter(Event{Step: step, Message: fmt.Sprintf(format, args...), Time: time.Now()})
+}
+
+// Run provisions the environment, reporting every step. The returned error
+// is also reported as a failed event.
+func (p *Provisioner) Run(ctx context.Context) error {
+	err := p.run(ctx)
+	if err != nil {
+		p.report(StepFailed, "%v", err)
+		return err
+	}
+	p.report(StepReady, "Environment is ready")
+	return nil
+}
+
+func (p *Provisioner) run(ctx context.Context) error {
+	if err := p.ensureCluster(); err != nil {
+		return err
+	}
+	clients, err := p.clients()
+	if err != nil {
+		return err
+	}
+	return p.applyScenarios(ctx, clients)
+}
+
+// ensureCluster creates the kind cluster unless it already exists.
+func (p *Provisioner) ensureCluster() error {
+	name := p.opts.ClusterName
+	existing, err := p.provider.List()
+	if err != nil {
+		return fmt.Errorf("listing kind clusters: %w", err)
+	}
+	if utils.Contains(existing, name) {
+		p.report(StepCreatingCluster, "Reusing kind cluster %s", name)
+		if err := p.provider.ExportKubeConfig(name, p.opts.KubeconfigPath, false); err != nil {
+			return fmt.Errorf("exporting kubeconfig of cluster %s: %w", name, err)
+		}
+		return nil
+	}
+
+	p.report(StepCreatingCluster, "Creating kind cluster %s with image %s", name, p.opts.NodeImage)
+	err = p.provider.Create(name,
+		cluster.CreateWithV1Alpha4Config(&v1alpha4.Cluster{
+			Nodes: []v1alpha4.Node{
+				{Role: v1alpha4.ControlPlaneRole},
+				{Role: v1alpha4.WorkerRole},
+				{Role: v1alpha4.WorkerRole},
+			},
+		}),
+		cluster.CreateWithNodeImage(p.opts.NodeImage),
+		cluster.CreateWithWaitForReady(p.opts.WaitForReady),
+		cluster.CreateWithKubeconfigPath(p.opts.KubeconfigPath),
+		cluster.CreateWithDisplayUsage(false),
+		cluster.CreateWithDisplaySalutation(false),
+	)
+	if err != nil {
+		return fmt.Errorf("creating kind cluster %s: %w", name, err)
+	}
+	return nil
+}
+
+// clients connects to the kind cluster with the credentials kind generated.
+func (p *Provisioner) clients() (*k8s.Clients, error) {
+	kubeconfig, err := p.provider.KubeConfig(p.opts.ClusterName, false)
+	if err != nil {
+		return nil, fmt.Errorf("reading kubeconfig of cluster %s: %w", p.opts.ClusterName, err)
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeconfig))
+	if err != nil {
+		return nil, err
+	}
+	return k8s.NewClients(config)
+}
+
+// applyScenarios sets up the scenario of every registered question.
+func (p *Provisioner) applyScenarios(ctx context.Context, clients *k8s.Clients) error {
+	for _, q := range registry.All() {
+		p.report(StepApplyingManifests, "Setting up %s", registry.Title(q))
+		if err := q.Setup(ctx, clients); err != nil {
+			return fmt.Errorf("setting up question %d: %w", q.ID(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Event codes of asciicast v2.
+const (
+	Output = "o"
+	Input  = "i"
+	Resize = "r"
+)
+
+// Header is the first line of an asciicast v2 file.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Event is a line of an asciicast v2 file: the seconds since the start of
+// the recording, the event code and its data.
+type Event struct {
+	Time float64
+	Code string
+	Data string
+}
+
+// MarshalJSON encodes the event as the [time, code, data] array of the
+// asciicast format.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.Time, e.Code, e.Data})
+}
+
+// UnmarshalJSON decodes a [time, code, data] array.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) != 3 {
+		return fmt.Errorf("event has %d fields, want 3", len(fields))
+	}
+	if err := json.Unmarshal(fields[0], &e.Time); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(fields[1], &e.Code); err != nil {
+		return err
+	}
+	return json.Unmarshal(fields[2], &e.Data)
+}
+
+// Decoder reads an asciicast v2 file event by event.
+type Decoder struct {
+	scanner *bufio.Scanner
+	Header  Header
+}
+
+// NewDecoder reads the header of an asciicast v2 file.
+func NewDecoder(r io.Reader) (*Decoder, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	d := &Decoder{scanner: scanner}
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &d.Header); err != nil {
+		return nil, fmt.Errorf("invalid asciicast header: %w", err)
+	}
+	if d.Header.Version != 2 {
+		return nil, fmt.Errorf("unsupported asciicast version %d", d.Header.Version)
+	}
+	return d, nil
+}
+
+// Next returns the next event, or io.EOF at the end of the file.
+func (d *Decoder) Next() (Event, error) {
+	for d.scanner.Scan() {
+		line := d.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var e Event
+		err := json.Unmarshal(line, &e)
+		return e, err
+	}
+	if err := d.scanner.Err(); err != nil {
+		return Event{}, err
+	}
+	return Event{}, io.EOF
+}
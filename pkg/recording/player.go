@@ -0,0 +1,112 @@
+package recording
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// MinSpeed and MaxSpeed bound the playback speed of a replay.
+	MinSpeed = 0.25
+	MaxSpeed = 16
+)
+
+// Player replays the events of a recording with their original timing,
+// scaled by a speed that may be changed during the replay.
+type Player struct {
+	dec *Decoder
+	// idleLimit caps the pauses between events, in recording time, so a
+	// learner thinking for minutes does not stall the replay. Zero keeps
+	// the pauses as recorded.
+	idleLimit time.Duration
+
+	mu      sync.Mutex
+	speed   float64
+	changed chan struct{}
+}
+
+// NewPlayer returns a player of the recording read by dec.
+func NewPlayer(dec *Decoder, speed float64, idleLimit time.Duration) *Player {
+	return &Player{dec: dec, idleLimit: idleLimit, speed: clampSpeed(speed), changed: make(chan struct{}, 1)}
+}
+
+// SetSpeed changes the playback speed, e.g. 2 plays twice as fast. The
+// pause in progress is shortened or stretched accordingly.
+func (p *Player) SetSpeed(speed float64) {
+	p.mu.Lock()
+	p.speed = clampSpeed(speed)
+	p.mu.Unlock()
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Speed returns the playback speed.
+func (p *Player) Speed() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.speed
+}
+
+// Play calls emit with every event of the recording at its time, until the
+// recording ends, emit fails or ctx is done.
+func (p *Player) Play(ctx context.Context, emit func(Event) error) error {
+	var last float64
+	for {
+		e, err := p.dec.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		pause := time.Duration((e.Time - last) * float64(time.Second))
+		last = e.Time
+		if p.idleLimit > 0 && pause > p.idleLimit {
+			pause = p.idleLimit
+		}
+		if err := p.wait(ctx, pause); err != nil {
+			return err
+		}
+		if err := emit(e); err != nil {
+			return err
+		}
+	}
+}
+
+// wait sleeps for a pause given in recording time, rescaling what is left
+// of it whenever the speed changes.
+func (p *Player) wait(ctx context.Context, pause time.Duration) error {
+	for pause > 0 {
+		speed := p.Speed()
+		start := time.Now()
+		timer := time.NewTimer(time.Duration(float64(pause) / speed))
+		select {
+		case <-timer.C:
+			return nil
+		case <-p.changed:
+			timer.Stop()
+			pause -= time.Duration(float64(time.Since(start)) * speed)
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func clampSpeed(speed float64) float64 {
+	switch {
+	case !(speed > 0): // also NaN
+		return 1
+	case speed < MinSpeed:
+		return MinSpeed
+	case speed > MaxSpeed:
+		return MaxSpeed
+	}
+	return speed
+}
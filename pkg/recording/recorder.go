@@ -0,0 +1,156 @@
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"kubelearn/pkg/terminal"
+)
+
+// Recorder writes the events of a terminal session to an asciicast v2 file
+// as they happen, so a recording survives a crash of the backend up to its
+// last event.
+type Recorder struct {
+	store *Store
+	file  *os.File
+	enc   *json.Encoder
+
+	mu    sync.Mutex
+	rec   Recording
+	start time.Time
+	err   error
+	// partial holds the start of a UTF-8 sequence split across reads,
+	// since asciicast data must be valid UTF-8.
+	partial []byte
+}
+
+func newRecorder(store *Store, file *os.File, rec Recording, header Header) (*Recorder, error) {
+	r := &Recorder{store: store, file: file, enc: json.NewEncoder(file), rec: rec, start: rec.StartedAt}
+	if err := r.enc.Encode(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Recording returns the metadata of the recording.
+func (r *Recorder) Recording() Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rec
+}
+
+// Output records data written by the shell.
+func (r *Recorder) Output(data []byte) {
+	r.mu.Lock()
+	data = append(r.partial, data...)
+	n := completeUTF8(data)
+	r.partial = append([]byte(nil), data[n:]...)
+	r.mu.Unlock()
+	if n > 0 {
+		r.event(Output, string(data[:n]))
+	}
+}
+
+// Input records keystrokes sent to the shell.
+func (r *Recorder) Input(data []byte) { r.event(Input, string(data)) }
+
+// Resize records a change of the terminal size.
+func (r *Recorder) Resize(cols, rows uint16) { r.event(Resize, fmt.Sprintf("%dx%d", cols, rows)) }
+
+func (r *Recorder) event(code, data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil || r.file == nil {
+		return
+	}
+	e := Event{Time: time.Since(r.start).Seconds(), Code: code, Data: data}
+	if err := r.enc.Encode(e); err != nil {
+		// Keep the terminal usable; the recording just ends here.
+		r.err = err
+	}
+}
+
+// Close finishes the file and stores the duration and size of the
+// recording.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return r.err
+	}
+	info, statErr := r.file.Stat()
+	err := r.file.Close()
+	r.file = nil
+	if statErr == nil {
+		r.rec.Size = info.Size()
+	}
+
+	now := time.Now()
+	r.rec.FinishedAt = &now
+	r.rec.Duration = now.Sub(r.start).Seconds()
+	if serr := r.store.save(r.rec); err == nil {
+		err = serr
+	}
+	if err == nil {
+		err = r.err
+	}
+	return err
+}
+
+// completeUTF8 returns the length of data without an incomplete UTF-8
+// sequence at its end.
+func completeUTF8(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(data[i]) {
+			continue
+		}
+		if !utf8.FullRune(data[i:]) {
+			return i
+		}
+		break
+	}
+	return len(data)
+}
+
+// process records everything that passes through a shell.
+type process struct {
+	terminal.Process
+	rec *Recorder
+}
+
+// Wrap returns a process that records the output, input and resizes of p
+// with rec. Closing it also closes rec.
+func Wrap(p terminal.Process, rec *Recorder) terminal.Process {
+	return &process{Process: p, rec: rec}
+}
+
+func (p *process) Read(b []byte) (int, error) {
+	n, err := p.Process.Read(b)
+	if n > 0 {
+		p.rec.Output(b[:n])
+	}
+	return n, err
+}
+
+func (p *process) Write(b []byte) (int, error) {
+	p.rec.Input(b)
+	return p.Process.Write(b)
+}
+
+func (p *process) Resize(cols, rows uint16) error {
+	p.rec.Resize(cols, rows)
+	return p.Process.Resize(cols, rows)
+}
+
+func (p *process) Close() error {
+	err := p.Process.Close()
+	if rerr := p.rec.Close(); err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -0,0 +1,162 @@
+// Package recording records terminal sessions as asciicast v2 files, the
+// format of asciinema, and replays them.
+package recording
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"kubelearn/pkg/storage"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var recordingsBucket = []byte("recordings")
+
+// ErrNotFound is returned for a recording that is not in the store.
+var ErrNotFound = errors.New("recording not found")
+
+// Recording describes a recorded terminal session.
+type Recording struct {
+	ID   string `json:"id"`
+	User string `json:"user"`
+	// Session is the quiz session the terminal was used in, if any.
+	Session    string     `json:"session,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	Duration   float64    `json:"durationSeconds"`
+	Width      uint16     `json:"width"`
+	Height     uint16     `json:"height"`
+	Size       int64      `json:"size"`
+}
+
+// Store keeps the asciicast files in a directory and indexes them in a
+// bbolt database.
+type Store struct {
+	db  *bolt.DB
+	dir string
+}
+
+// New returns a store that writes the recordings to dir, creating it if
+// needed, and indexes them in db.
+func New(db *bolt.DB, dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating recordings directory: %w", err)
+	}
+	if err := storage.CreateBuckets(db, recordingsBucket); err != nil {
+		return nil, err
+	}
+	return &Store{db: db, dir: dir}, nil
+}
+
+// Create starts a recording of a terminal of user with the given size,
+// linked to the quiz session with the given ID when it is not empty.
+func (s *Store) Create(user, session string, cols, rows uint16) (*Recorder, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	rec := Recording{
+		ID:        id,
+		User:      user,
+		Session:   session,
+		StartedAt: time.Now(),
+		Width:     cols,
+		Height:    rows,
+	}
+	if err := s.save(rec); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(s.path(id), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	title := "kubelearn terminal of " + user
+	if session != "" {
+		title += ", session " + session
+	}
+	return newRecorder(s, f, rec, Header{
+		Version:   2,
+		Width:     int(cols),
+		Height:    int(rows),
+		Timestamp: rec.StartedAt.Unix(),
+		Title:     title,
+		Env:       map[string]string{"TERM": "xterm-256color"},
+	})
+}
+
+// Get returns the recording with the given ID.
+func (s *Store) Get(id string) (Recording, error) {
+	var rec Recording
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(recordingsBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, &rec)
+	})
+	return rec, err
+}
+
+// Open opens the asciicast file of the recording with the given ID.
+func (s *Store) Open(id string) (*os.File, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+	return os.Open(s.path(id))
+}
+
+// List returns the recordings of user, or of every user when user is
+// empty, newest first. When session is not empty only the recordings of
+// that quiz session are returned.
+func (s *Store) List(user, session string) ([]Recording, error) {
+	recordings := []Recording{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(recordingsBucket).ForEach(func(_, data []byte) error {
+			var rec Recording
+			if err := json.Unmarshal(data, &rec); err != nil {
+				return err
+			}
+			if (user == "" || rec.User == user) && (session == "" || rec.Session == session) {
+				recordings = append(recordings, rec)
+			}
+			return nil
+		})
+	})
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartedAt.After(recordings[j].StartedAt)
+	})
+	return recordings, err
+}
+
+func (s *Store) save(rec Recording) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(recordingsBucket).Put([]byte(rec.ID), data)
+	})
+}
+
+// path is the asciicast file of a recording. IDs are only ever generated by
+// newID, so they are safe to use as file names.
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".cast")
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
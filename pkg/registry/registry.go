@@ -0,0 +1,229 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"kubelearn/manifests"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/utils"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	Easy   = "Easy"
+	Medium = "Medium"
+	Hard   = "Hard"
+)
+
+// Question is a quiz question. The metadata methods never touch the cluster,
+// so listing questions is cheap; grading only happens in Check.
+type Question interface {
+	ID() int
+	Prompt() string
+	Difficulty() string
+	Namespace() string
+	CreatedNamespace() string
+	Tags() []string
+	Hints() []string
+	Setup(ctx context.Context, clients *k8s.Clients) error
+	Check(ctx context.Context, clients *k8s.Clients) utils.Result
+	Cleanup(ctx context.Context, clients *k8s.Clients) error
+}
+
+// Meta holds the static description of a question.
+type Meta struct {
+	ID         int
+	Prompt     string
+	Difficulty string
+	Namespace  string
+	// CreatedNamespace is a namespace the learner is asked to create, such
+	// as europe. It is mapped like Namespace, but never set up.
+	CreatedNamespace string
+	Tags             []string
+	Hints            []string
+	// Scenario names the manifests in the manifests directory that the
+	// question starts from, such as a broken pod to troubleshoot.
+	Scenario []string
+}
+
+// CheckFunc grades the cluster state against the criteria of a question.
+type CheckFunc func(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion
+
+type question struct {
+	meta  Meta
+	check CheckFunc
+}
+
+// New returns a Question graded by check that needs no setup or cleanup.
+func New(meta Meta, check CheckFunc) Question {
+	return &question{meta: meta, check: check}
+}
+
+func (q *question) ID() int                  { return q.meta.ID }
+func (q *question) Prompt() string           { return q.meta.Prompt }
+func (q *question) Difficulty() string       { return q.meta.Difficulty }
+func (q *question) Namespace() string        { return q.meta.Namespace }
+func (q *question) CreatedNamespace() string { return q.meta.CreatedNamespace }
+func (q *question) Tags() []string           { return q.meta.Tags }
+func (q *question) Hints() []string          { return q.meta.Hints }
+
+// Setup creates the namespace of the question and applies its scenario
+// manifests.
+func (q *question) Setup(ctx context.Context, clients *k8s.Clients) error {
+	if q.meta.Namespace != "" {
+		if err := clients.EnsureNamespace(ctx, q.meta.Namespace); err != nil {
+			return err
+		}
+	}
+	for _, name := range q.meta.Scenario {
+		data, err := manifests.Read(name)
+		if err != nil {
+			return err
+		}
+		if err := clients.Apply(ctx, data); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (q *question) Check(ctx context.Context, clients *k8s.Clients) utils.Result {
+	return NewResult(q, q.check(ctx, clients.Clientset()))
+}
+
+// Cleanup deletes the objects created from the scenario manifests.
+func (q *question) Cleanup(ctx context.Context, clients *k8s.Clients) error {
+	for _, name := range q.meta.Scenario {
+		data, err := manifests.Read(name)
+		if err != nil {
+			return err
+		}
+		if err := clients.Delete(ctx, data); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Reset tears the scenario of q down and provisions it again, undoing any
+// change the learner made to it.
+func Reset(ctx context.Context, q Question, clients *k8s.Clients) error {
+	if err := q.Cleanup(ctx, clients); err != nil {
+		return err
+	}
+	return q.Setup(ctx, clients)
+}
+
+// NewResult builds the result of grading q; it passes when every criterion
+// passed.
+func NewResult(q Question, criteria []utils.Criterion) utils.Result {
+	return utils.Result{
+		ID:         q.ID(),
+		TestName:   Title(q),
+		Passed:     utils.AllPassed(criteria),
+		Difficulty: q.Difficulty(),
+		Criteria:   criteria,
+	}
+}
+
+// Title returns the numbered prompt shown to learners, e.g.
+// "Question 4 - Create a namespace europe".
+func Title(q Question) string {
+	return fmt.Sprintf("Question %d - %s", q.ID(), q.Prompt())
+}
+
+// Info is the JSON-friendly description of a question.
+type Info struct {
+	ID         int    `json:"id"`
+	Title      string `json:"title"`
+	Prompt     string `json:"prompt"`
+	Difficulty string `json:"difficulty"`
+	// Namespace is empty for questions about cluster-scoped resources.
+	Namespace        string   `json:"namespace,omitempty"`
+	CreatedNamespace string   `json:"createdNamespace,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+	Hints            []string `json:"hints,omitempty"`
+}
+
+// Describe returns the description of q without grading it.
+func Describe(q Question) Info {
+	return Info{
+		ID:               q.ID(),
+		Title:            Title(q),
+		Prompt:           q.Prompt(),
+		Difficulty:       q.Difficulty(),
+		Namespace:        q.Namespace(),
+		CreatedNamespace: q.CreatedNamespace(),
+		Tags:             q.Tags(),
+		Hints:            q.Hints(),
+	}
+}
+
+var (
+	mu        sync.RWMutex
+	questions = map[int]Question{}
+)
+
+// Register adds a question to the registry. It is meant to be called from
+// the init function of the file that implements the checker.
+func Register(q Question) {
+	if err := Add(q); err != nil {
+		panic(err)
+	}
+}
+
+// Add adds a question to the registry, failing if its ID is already taken.
+func Add(q Question) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := questions[q.ID()]; ok {
+		return fmt.Errorf("registry: question %d registered twice", q.ID())
+	}
+	questions[q.ID()] = q
+	return nil
+}
+
+// All returns every registered question ordered by ID.
+func All() []Question {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	all := make([]Question, 0, len(questions))
+	for _, q := range questions {
+		all = append(all, q)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID() < all[j].ID() })
+	return all
+}
+
+// Get returns the question registered with the given ID.
+func Get(id int) (Question, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	q, ok := questions[id]
+	return q, ok
+}
+
+// List describes every registered question without touching the cluster.
+func List() []Info {
+	var infos []Info
+	for _, q := range All() {
+		infos = append(infos, Describe(q))
+	}
+	return infos
+}
+
+// CheckAll grades every registered question in ID order.
+func CheckAll(ctx context.Context, clients *k8s.Clients) []utils.Result {
+	var results []utils.Result
+	for _, q := range All() {
+		results = append(results, q.Check(ctx, clients))
+	}
+	return results
+}
@@ -2,21 +2,31 @@ package easy
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreatePod(clientset *kubernetes.Clientset) utils.Result {
-	pod, err := clientset.CoreV1().Pods("default").Get(context.TODO(), "nginx", metav1.GetOptions{})
-	passed := err == nil &&
-		pod.Spec.Containers[0].Image == "nginx:alpine" &&
-		pod.Name == "nginx"
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         1,
+		Prompt:     "Create a pod nginx name with nginx:alpine image",
+		Difficulty: registry.Easy,
+		Namespace:  "default",
+		Tags:       []string{"pods"},
+	}, CreatePod))
+}
+
+func CreatePod(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	pod, err := clientset.CoreV1().Pods(k8s.Namespace(ctx, "default")).Get(ctx, "nginx", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("pod nginx", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 1 - Create a pod nginx name with nginx:alpine image",
-		Passed:     passed,
-		Difficulty: "Easy",
+	return []utils.Criterion{
+		utils.ExpectAny("container image", "nginx:alpine", utils.Images(pod.Spec.Containers)),
 	}
 }
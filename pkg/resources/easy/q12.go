@@ -2,19 +2,31 @@ package easy
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateSecret(clientset *kubernetes.Clientset) utils.Result {
-	secret, err := clientset.CoreV1().Secrets("colors").Get(context.TODO(), "secret-colors", metav1.GetOptions{})
-	passed := err == nil && string(secret.Data["color"]) == "red"
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         12,
+		Prompt:     "Create a secret secret-colors with data color=red in colors namespace",
+		Difficulty: registry.Easy,
+		Namespace:  "colors",
+		Tags:       []string{"secrets"},
+	}, CreateSecret))
+}
+
+func CreateSecret(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	secret, err := clientset.CoreV1().Secrets(k8s.Namespace(ctx, "colors")).Get(ctx, "secret-colors", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("secret secret-colors", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 12 - Create a secret secret-colors with data color=red in colors namespace",
-		Passed:     passed,
-		Difficulty: "Easy",
+	return []utils.Criterion{
+		utils.Expect("data color", "red", string(secret.Data["color"])),
 	}
 }
@@ -2,19 +2,31 @@ package easy
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateServiceAccount(clientset *kubernetes.Clientset) utils.Result {
-	sa, err := clientset.CoreV1().ServiceAccounts("default").Get(context.TODO(), "america-sa", metav1.GetOptions{})
-	passed := err == nil && sa.Name == "america-sa"
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         14,
+		Prompt:     "Create a service account america-sa in default namespace",
+		Difficulty: registry.Easy,
+		Namespace:  "default",
+		Tags:       []string{"serviceaccounts"},
+	}, CreateServiceAccount))
+}
+
+func CreateServiceAccount(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	sa, err := clientset.CoreV1().ServiceAccounts(k8s.Namespace(ctx, "default")).Get(ctx, "america-sa", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("service account america-sa", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 14 - Create a service account america-sa in default namespace",
-		Passed:     passed,
-		Difficulty: "Easy",
+	return []utils.Criterion{
+		utils.Expect("service account name", "america-sa", sa.Name),
 	}
 }
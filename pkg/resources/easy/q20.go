@@ -2,19 +2,32 @@ package easy
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateDeploymentYellow(clientset *kubernetes.Clientset) utils.Result {
-	deployment, err := clientset.AppsV1().Deployments("colors").Get(context.TODO(), "yellow-deployment", metav1.GetOptions{})
-	passed := err == nil && deployment.Spec.Template.Spec.Containers[0].Image == "bonovoo/node-app:1.0" && *deployment.Spec.Replicas == 2
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         20,
+		Prompt:     "Create a deployment yellow-deployment with bonovoo/node-app:1.0 image and 2 replicas in namespace colors",
+		Difficulty: registry.Easy,
+		Namespace:  "colors",
+		Tags:       []string{"deployments"},
+	}, CreateDeploymentYellow))
+}
+
+func CreateDeploymentYellow(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	deployment, err := clientset.AppsV1().Deployments(k8s.Namespace(ctx, "colors")).Get(ctx, "yellow-deployment", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("deployment yellow-deployment", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 20 - Create a deployment yellow-deployment with bonovoo/node-app:1.0 image and 2 replicas in namespace colors",
-		Passed:     passed,
-		Difficulty: "Easy",
+	return []utils.Criterion{
+		utils.ExpectAny("container image", "bonovoo/node-app:1.0", utils.Images(deployment.Spec.Template.Spec.Containers)),
+		utils.Expect("replicas", 2, utils.Value(deployment.Spec.Replicas)),
 	}
 }
@@ -3,19 +3,33 @@ package easy
 import (
 	"context"
 
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateNamespace(clientset *kubernetes.Clientset) utils.Result {
-	namespace, err := clientset.CoreV1().Namespaces().Get(context.TODO(), "europe", metav1.GetOptions{})
-	passed := err == nil && namespace.Name == "europe"
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:               4,
+		Prompt:           "Create a namespace europe",
+		Difficulty:       registry.Easy,
+		CreatedNamespace: "europe",
+		Tags:             []string{"namespaces"},
+	}, CreateNamespace))
+}
+
+func CreateNamespace(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	name := k8s.Namespace(ctx, "europe")
+	namespace, err := clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("namespace "+name, err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 4 - Create a namespace europe",
-		Passed:     passed,
-		Difficulty: "Easy",
+	return []utils.Criterion{
+		utils.Expect("namespace phase", corev1.NamespaceActive, namespace.Status.Phase),
 	}
 }
@@ -0,0 +1,68 @@
+package hard
+
+import (
+	"context"
+	"testing"
+
+	"kubelearn/pkg/utils"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCreatePodVolumeClaimWithSidecar(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "webserver", Namespace: "public"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{Name: "html", VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "unicorn-pvc"},
+			}}},
+			Containers: []corev1.Container{
+				{Name: "logger", Image: "busybox:1.28"},
+				{Name: "web", Image: "nginx:alpine", VolumeMounts: []corev1.VolumeMount{{Name: "html", MountPath: "/usr/share/nginx/html"}}},
+			},
+		},
+	})
+	if criteria := CreatePodVolumeClaim(context.Background(), clientset); !utils.AllPassed(criteria) {
+		t.Errorf("criteria %+v, want all passed", criteria)
+	}
+}
+
+func TestCreateServiceForYellowWithSecondPort(t *testing.T) {
+	labels := map[string]string{"app": "yellow-deployment"}
+	clientset := fake.NewSimpleClientset(
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "yellow-service", Namespace: "colors"},
+			Spec: corev1.ServiceSpec{
+				Selector: labels,
+				Ports: []corev1.ServicePort{
+					{Name: "metrics", Port: 9090, TargetPort: intstr.FromInt(9090)},
+					{Name: "http", Port: 80, TargetPort: intstr.FromInt(3000)},
+				},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "yellow-deployment", Namespace: "colors"},
+			Spec:       appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}}},
+		},
+	)
+	if criteria := CreateServiceForYellow(context.Background(), clientset); !utils.AllPassed(criteria) {
+		t.Errorf("criteria %+v, want all passed", criteria)
+	}
+}
+
+func TestCreateServiceForYellowWrongTargetPort(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "yellow-service", Namespace: "colors"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "yellow-deployment"},
+			Ports:    []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+		},
+	})
+	if criteria := CreateServiceForYellow(context.Background(), clientset); utils.AllPassed(criteria) {
+		t.Errorf("criteria %+v, want a failed target port", criteria)
+	}
+}
@@ -2,20 +2,32 @@ package hard
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CheckPodError(clientset *kubernetes.Clientset) utils.Result {
-	pod, err := clientset.CoreV1().Pods("bandai").Get(context.TODO(), "gundamv", metav1.GetOptions{})
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         10,
+		Prompt:     "Identify and fix the issue in the pod gundamv in namespace bandai",
+		Difficulty: registry.Hard,
+		Namespace:  "bandai",
+		Tags:       []string{"pods", "troubleshooting"},
+		Scenario:   []string{"klearn-0001-sample.yaml"},
+	}, CheckPodError))
+}
 
-	passed := err == nil && pod.Spec.Containers[0].Image == "nginx:alpine"
+func CheckPodError(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	pod, err := clientset.CoreV1().Pods(k8s.Namespace(ctx, "bandai")).Get(ctx, "gundamv", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("pod gundamv", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 10 - Identify and fix the issue in the pod gundamv in namespace bandai",
-		Passed:     passed,
-		Difficulty: "Hard",
+	return []utils.Criterion{
+		utils.ExpectAny("container image", "nginx:alpine", utils.Images(pod.Spec.Containers)),
 	}
 }
@@ -2,41 +2,41 @@ package hard
 
 import (
 	"context"
+	"kubelearn/pkg/assertion"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
-	v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateNetPolRule(clientset *kubernetes.Clientset) utils.Result {
-	netPol, err := clientset.NetworkingV1().NetworkPolicies("colors").Get(context.TODO(), "allow-policy-colors", metav1.GetOptions{})
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         11,
+		Prompt:     "Create a network policy allow-policy-colors to allow redmobile-webserver to access bluemobile-dbcache",
+		Difficulty: registry.Hard,
+		Namespace:  "colors",
+		Tags:       []string{"networkpolicies", "networking"},
+		Scenario:   []string{"klearn-0000-sample.yaml"},
+	}, CreateNetPolRule))
+}
 
-	passed := err == nil && hasCorrectIngressRule(netPol.Spec.Ingress)
+// allowsFrontendToRedis matches an ingress rule, in any position, that
+// selects the tier=frontend pods by label or expression and opens port 6379.
+const allowsFrontendToRedis = `has(object.spec.ingress) && object.spec.ingress.exists(rule,
+	has(rule.from) && rule.from.exists(peer, has(peer.podSelector) && (
+		(has(peer.podSelector.matchLabels) && 'tier' in peer.podSelector.matchLabels && peer.podSelector.matchLabels.tier == 'frontend') ||
+		(has(peer.podSelector.matchExpressions) && peer.podSelector.matchExpressions.exists(e, e.key == 'tier' && e.operator == 'In' && 'frontend' in e.values)))) &&
+	has(rule.ports) && rule.ports.exists(p, has(p.port) && p.port == 6379))`
 
-	return utils.Result{
-		TestName:   "Question 11 - Create a network policy allow-policy-colors to allow redmobile-webserver to access bluemobile-dbcache",
-		Passed:     passed,
-		Difficulty: "Hard",
+func CreateNetPolRule(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	netPol, err := clientset.NetworkingV1().NetworkPolicies(k8s.Namespace(ctx, "colors")).Get(ctx, "allow-policy-colors", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("network policy allow-policy-colors", err)}
 	}
-}
 
-// hasCorrectIngressRule checks if the network policy has the correct ingress rule
-func hasCorrectIngressRule(ingressRules []v1.NetworkPolicyIngressRule) bool {
-	for _, rule := range ingressRules {
-		for _, from := range rule.From {
-			if from.PodSelector != nil {
-				selector, err := metav1.LabelSelectorAsSelector(from.PodSelector)
-				if err == nil && selector.Matches(labels.Set{"tier": "frontend"}) {
-					for _, port := range rule.Ports {
-						if port.Port != nil && port.Port.IntVal == 6379 {
-							return true
-						}
-					}
-				}
-			}
-		}
+	return []utils.Criterion{
+		assertion.Criterion("ingress from tier=frontend on port 6379", allowsFrontendToRedis, netPol),
 	}
-	return false
 }
@@ -2,24 +2,43 @@ package hard
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreatePodAddSecret(clientset *kubernetes.Clientset) utils.Result {
-	pod, err := clientset.CoreV1().Pods("colors").Get(context.TODO(), "purple", metav1.GetOptions{})
-	secret, err := clientset.CoreV1().Secrets("colors").Get(context.TODO(), "secret-purple", metav1.GetOptions{})
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         13,
+		Prompt:     "Add a secret secret-purple with data singer=prince to the pod purple with image redis:alpine in colors namespace",
+		Difficulty: registry.Hard,
+		Namespace:  "colors",
+		Tags:       []string{"pods", "secrets"},
+	}, CreatePodAddSecret))
+}
+
+func CreatePodAddSecret(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	var criteria []utils.Criterion
 
-	passed := err == nil &&
-		pod.Spec.Volumes[0].Secret.SecretName == "secret-purple" &&
-		string(secret.Data["singer"]) == "prince" &&
-		pod.Spec.Containers[0].Image == "redis:alpine"
+	secret, err := clientset.CoreV1().Secrets(k8s.Namespace(ctx, "colors")).Get(ctx, "secret-purple", metav1.GetOptions{})
+	if err != nil {
+		criteria = append(criteria, utils.Missing("secret secret-purple", err))
+	} else {
+		criteria = append(criteria, utils.Expect("secret data singer", "prince", string(secret.Data["singer"])))
+	}
 
-	return utils.Result{
-		TestName:   "Question 13 - Add a secret secret-purple with data singer=prince to the pod purple with image redis:alpine in colors namespace",
-		Passed:     passed,
-		Difficulty: "Hard",
+	pod, err := clientset.CoreV1().Pods(k8s.Namespace(ctx, "colors")).Get(ctx, "purple", metav1.GetOptions{})
+	if err != nil {
+		criteria = append(criteria, utils.Missing("pod purple", err))
+	} else {
+		criteria = append(criteria,
+			utils.ExpectAny("container image", "redis:alpine", utils.Images(pod.Spec.Containers)),
+			utils.Expect("uses secret-purple", true, utils.ReferencesSecret(pod.Spec, "secret-purple")),
+		)
 	}
+
+	return criteria
 }
@@ -2,23 +2,48 @@ package hard
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateServiceForYellow(clientset *kubernetes.Clientset) utils.Result {
-	service, err := clientset.CoreV1().Services("colors").Get(context.TODO(), "yellow-service", metav1.GetOptions{})
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         21,
+		Prompt:     "Create a service yellow-service for the deployment yellow-deployment in namespace colors with port 80 and target port 3000",
+		Difficulty: registry.Hard,
+		Namespace:  "colors",
+		Tags:       []string{"services", "networking"},
+	}, CreateServiceForYellow))
+}
+
+func CreateServiceForYellow(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	service, err := clientset.CoreV1().Services(k8s.Namespace(ctx, "colors")).Get(ctx, "yellow-service", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("service yellow-service", err)}
+	}
 
-	passed := err == nil &&
-		service.Spec.Ports[0].Port == 80 &&
-		service.Spec.Ports[0].TargetPort.IntVal == 3000 &&
-		service.Spec.Selector["app"] == "yellow-deployment"
+	criteria := []utils.Criterion{
+		utils.ExpectAny("port", 80, utils.ServicePortNumbers(service.Spec.Ports)),
+	}
+	if port, ok := utils.FindServicePort(service.Spec.Ports, 80); ok {
+		criteria = append(criteria, utils.Expect("target port", 3000, port.TargetPort.String()))
+	}
 
-	return utils.Result{
-		TestName:   "Question 21 - Create a service yellow-service for the deployment yellow-deployment in namespace colors with port 80 and target port 3000",
-		Passed:     passed,
-		Difficulty: "Hard",
+	// Any selector that matches the pods of the deployment is accepted.
+	deployment, err := clientset.AppsV1().Deployments(k8s.Namespace(ctx, "colors")).Get(ctx, "yellow-deployment", metav1.GetOptions{})
+	if err != nil {
+		return append(criteria, utils.Expect("selector app", "yellow-deployment", service.Spec.Selector["app"]))
 	}
+	selector := labels.SelectorFromSet(service.Spec.Selector)
+	return append(criteria, utils.Criterion{
+		Name:     "selector",
+		Expected: "matches pods of yellow-deployment",
+		Observed: selector.String(),
+		Passed:   len(service.Spec.Selector) > 0 && selector.Matches(labels.Set(deployment.Spec.Template.Labels)),
+	})
 }
@@ -2,24 +2,41 @@ package hard
 
 import (
 	"context"
+	"kubelearn/pkg/assertion"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateIngressYellow(clientset *kubernetes.Clientset) utils.Result {
-	ingress, err := clientset.NetworkingV1().Ingresses("colors").Get(context.TODO(), "ingress-colors", metav1.GetOptions{})
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         22,
+		Prompt:     "Create an ingress ingress-colors with host yellow.com, path /yellow, and service yellow-service in namespace colors",
+		Difficulty: registry.Hard,
+		Namespace:  "colors",
+		Tags:       []string{"ingress", "networking"},
+	}, CreateIngressYellow))
+}
+
+const (
+	hasYellowHost = `has(object.spec.rules) && object.spec.rules.exists(r, has(r.host) && r.host == 'yellow.com')`
 
-	passed := err == nil && len(ingress.Spec.Rules) > 0 &&
-		ingress.Spec.Rules[0].Host == "yellow.com" &&
-		len(ingress.Spec.Rules[0].HTTP.Paths) > 0 &&
-		ingress.Spec.Rules[0].HTTP.Paths[0].Path == "/yellow" &&
-		ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name == "yellow-service"
+	routesYellowPath = `has(object.spec.rules) && object.spec.rules.exists(r, has(r.host) && r.host == 'yellow.com' &&
+	has(r.http) && r.http.paths.exists(p, has(p.path) && p.path == '/yellow' &&
+		has(p.backend.service) && p.backend.service.name == 'yellow-service'))`
+)
+
+func CreateIngressYellow(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	ingress, err := clientset.NetworkingV1().Ingresses(k8s.Namespace(ctx, "colors")).Get(ctx, "ingress-colors", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("ingress ingress-colors", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 22 - Create an ingress ingress-colors with host yellow.com, path /yellow, and service yellow-service in namespace colors",
-		Passed:     passed,
-		Difficulty: "Hard",
+	return []utils.Criterion{
+		assertion.Criterion("host yellow.com", hasYellowHost, ingress),
+		assertion.Criterion("path /yellow to yellow-service", routesYellowPath, ingress),
 	}
 }
@@ -2,31 +2,41 @@ package hard
 
 import (
 	"context"
+	"fmt"
+	"kubelearn/pkg/assertion"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateRoleOne(clientset *kubernetes.Clientset) utils.Result {
-	role, err := clientset.RbacV1().Roles("fruits").Get(context.TODO(), "apple-one", metav1.GetOptions{})
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         23,
+		Prompt:     "Create a role apple-one with verbs get, list, watch in namespace fruits",
+		Difficulty: registry.Hard,
+		Namespace:  "fruits",
+		Tags:       []string{"rbac"},
+	}, CreateRoleOne))
+}
 
-	expectedVerbs := []string{"get", "list", "watch"}
-	passed := err == nil && len(role.Rules) > 0 && len(role.Rules[0].Resources) > 0 &&
-		role.Rules[0].Resources[0] == "pods"
+// grantsPodVerb matches any rule of the role that allows the verb on pods,
+// so the verbs may be split across rules or granted with a wildcard.
+const grantsPodVerb = `has(object.rules) && object.rules.exists(r,
+	has(r.resources) && ('pods' in r.resources || '*' in r.resources) &&
+	('%s' in r.verbs || '*' in r.verbs))`
 
-	if passed {
-		for _, verb := range expectedVerbs {
-			if !utils.Contains(role.Rules[0].Verbs, verb) {
-				passed = false
-				break
-			}
-		}
+func CreateRoleOne(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	role, err := clientset.RbacV1().Roles(k8s.Namespace(ctx, "fruits")).Get(ctx, "apple-one", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("role apple-one", err)}
 	}
 
-	return utils.Result{
-		TestName:   "Question 23 - Create a role apple-one with verbs get, list, watch in namespace fruits",
-		Passed:     passed,
-		Difficulty: "Hard",
+	var criteria []utils.Criterion
+	for _, verb := range []string{"get", "list", "watch"} {
+		criteria = append(criteria, assertion.Criterion("verb "+verb+" on pods", fmt.Sprintf(grantsPodVerb, verb), role))
 	}
+	return criteria
 }
@@ -2,24 +2,36 @@ package hard
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateStatefulSet(clientset *kubernetes.Clientset) utils.Result {
-	statefulset, err := clientset.AppsV1().StatefulSets("default").Get(context.TODO(), "statefulset-gain", metav1.GetOptions{})
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         26,
+		Prompt:     "Create a statefulset statefulset-gain with image busybox:1.28, command 'sleep 3600', and 3 replicas",
+		Difficulty: registry.Hard,
+		Namespace:  "default",
+		Tags:       []string{"statefulsets"},
+	}, CreateStatefulSet))
+}
 
-	passed := err == nil &&
-		statefulset.Name == "statefulset-gain" &&
-		statefulset.Spec.Template.Spec.Containers[0].Image == "busybox:1.28" &&
-		statefulset.Spec.Template.Spec.Containers[0].Command[0] == "sleep 3600" &&
-		statefulset.Status.ReadyReplicas == 3
+func CreateStatefulSet(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	statefulset, err := clientset.AppsV1().StatefulSets(k8s.Namespace(ctx, "default")).Get(ctx, "statefulset-gain", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("statefulset statefulset-gain", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 26 - Create a statefulset statefulset-gain with image busybox:1.28, command 'sleep 3600', and 3 replicas",
-		Passed:     passed,
-		Difficulty: "Hard",
+	containers := statefulset.Spec.Template.Spec.Containers
+	criteria := []utils.Criterion{
+		utils.ExpectAny("container image", "busybox:1.28", utils.Images(containers)),
+	}
+	if container, ok := utils.FindContainerByImage(containers, "busybox:1.28"); ok {
+		criteria = append(criteria, utils.Expect("container command", "sleep 3600", utils.CommandLine(container)))
 	}
+	return append(criteria, utils.Expect("ready replicas", 3, statefulset.Status.ReadyReplicas))
 }
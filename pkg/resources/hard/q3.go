@@ -2,26 +2,40 @@ package hard
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateDeploymentAndService(clientset *kubernetes.Clientset) utils.Result {
-	deployment, err := clientset.AppsV1().Deployments("latam").Get(context.TODO(), "redis", metav1.GetOptions{})
-	service, err := clientset.CoreV1().Services("latam").Get(context.TODO(), "redis-service", metav1.GetOptions{})
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         3,
+		Prompt:     "Create a deployment redis with redis:alpine image and a service named redis-service on port 6379 in namespace latam",
+		Difficulty: registry.Hard,
+		Namespace:  "latam",
+		Tags:       []string{"deployments", "services"},
+	}, CreateDeploymentAndService))
+}
+
+func CreateDeploymentAndService(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	var criteria []utils.Criterion
 
-	passed := err == nil &&
-		service != nil &&
-		deployment.Name == "redis" &&
-		service.Name == "redis-service" &&
-		service.Spec.Ports[0].Port == 6379 &&
-		deployment.Spec.Template.Spec.Containers[0].Image == "redis:alpine"
+	deployment, err := clientset.AppsV1().Deployments(k8s.Namespace(ctx, "latam")).Get(ctx, "redis", metav1.GetOptions{})
+	if err != nil {
+		criteria = append(criteria, utils.Missing("deployment redis", err))
+	} else {
+		criteria = append(criteria, utils.ExpectAny("deployment image", "redis:alpine", utils.Images(deployment.Spec.Template.Spec.Containers)))
+	}
 
-	return utils.Result{
-		TestName:   "Question 3 - Create a deployment redis with redis:alpine image and a service named redis-service on port 6379 in namespace latam",
-		Passed:     passed,
-		Difficulty: "Hard",
+	service, err := clientset.CoreV1().Services(k8s.Namespace(ctx, "latam")).Get(ctx, "redis-service", metav1.GetOptions{})
+	if err != nil {
+		criteria = append(criteria, utils.Missing("service redis-service", err))
+	} else {
+		criteria = append(criteria, utils.ExpectAny("service port", 6379, utils.ServicePortNumbers(service.Spec.Ports)))
 	}
+
+	return criteria
 }
@@ -2,24 +2,43 @@ package hard
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreatePodVolumeClaim(clientset *kubernetes.Clientset) utils.Result {
-	pod, err := clientset.CoreV1().Pods("public").Get(context.TODO(), "webserver", metav1.GetOptions{})
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         9,
+		Prompt:     "Create a pod webserver in public namespace with nginx:alpine image, volume mount /usr/share/nginx/html, and a persistent volume claim unicorn-pvc",
+		Difficulty: registry.Hard,
+		Namespace:  "public",
+		Tags:       []string{"pods", "storage"},
+	}, CreatePodVolumeClaim))
+}
 
-	passed := err == nil &&
-		pod.Spec.Containers[0].Image == "nginx:alpine" &&
-		pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName == "unicorn-pvc" &&
-		pod.Spec.Containers[0].VolumeMounts[0].MountPath == "/usr/share/nginx/html" &&
-		pod.Spec.Volumes[0].Name == "unicorn-pv"
+func CreatePodVolumeClaim(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	pod, err := clientset.CoreV1().Pods(k8s.Namespace(ctx, "public")).Get(ctx, "webserver", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("pod webserver", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 9 - Create a pod webserver in public namespace with nginx:alpine image, volume mount /usr/share/nginx/html, and a persistent volume claim unicorn-pvc",
-		Passed:     passed,
-		Difficulty: "Hard",
+	criteria := []utils.Criterion{
+		utils.ExpectAny("container image", "nginx:alpine", utils.Images(pod.Spec.Containers)),
+	}
+
+	volume, ok := utils.FindVolumeByClaim(pod.Spec.Volumes, "unicorn-pvc")
+	if !ok {
+		return append(criteria, utils.Expect("volume claim", "unicorn-pvc", "none"))
+	}
+	var mountedVolume interface{}
+	if container, ok := utils.FindContainerByImage(pod.Spec.Containers, "nginx:alpine"); ok {
+		if mount, ok := utils.FindVolumeMount(container, "/usr/share/nginx/html"); ok {
+			mountedVolume = mount.Name
+		}
 	}
+	return append(criteria, utils.Expect("volume mounted at /usr/share/nginx/html", volume.Name, mountedVolume))
 }
@@ -2,19 +2,32 @@ package medium
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func AddServiceAccountToDeployment(clientset *kubernetes.Clientset) utils.Result {
-	deploy, err := clientset.AppsV1().Deployments("default").Get(context.TODO(), "mark42", metav1.GetOptions{})
-	passed := err == nil && deploy.Spec.Template.Spec.ServiceAccountName == "america-sa"
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         15,
+		Prompt:     "Add service account america-sa to the deployment mark42",
+		Difficulty: registry.Medium,
+		Namespace:  "default",
+		Tags:       []string{"deployments", "serviceaccounts"},
+		Scenario:   []string{"klearn-0003-sample.yaml"},
+	}, AddServiceAccountToDeployment))
+}
+
+func AddServiceAccountToDeployment(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	deploy, err := clientset.AppsV1().Deployments(k8s.Namespace(ctx, "default")).Get(ctx, "mark42", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("deployment mark42", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 15 - Add service account america-sa to the deployment mark42",
-		Passed:     passed,
-		Difficulty: "Medium",
+	return []utils.Criterion{
+		utils.Expect("service account", "america-sa", deploy.Spec.Template.Spec.ServiceAccountName),
 	}
 }
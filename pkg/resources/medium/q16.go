@@ -3,20 +3,32 @@ package medium
 import (
 	"context"
 
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func ChangeReplicaCount(clientset *kubernetes.Clientset) utils.Result {
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         16,
+		Prompt:     "Change the replica count of the deployment mark42 to 5",
+		Difficulty: registry.Medium,
+		Namespace:  "default",
+		Tags:       []string{"deployments", "scaling"},
+		Scenario:   []string{"klearn-0003-sample.yaml"},
+	}, ChangeReplicaCount))
+}
 
-	deploy, err := clientset.AppsV1().Deployments("default").Get(context.TODO(), "mark42", metav1.GetOptions{})
-	passed := err == nil && *deploy.Spec.Replicas == 5
+func ChangeReplicaCount(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	deploy, err := clientset.AppsV1().Deployments(k8s.Namespace(ctx, "default")).Get(ctx, "mark42", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("deployment mark42", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 16 - Change the replica count of the deployment mark42 to 5",
-		Passed:     passed,
-		Difficulty: "Medium",
+	return []utils.Criterion{
+		utils.Expect("replicas", 5, utils.Value(deploy.Spec.Replicas)),
 	}
 }
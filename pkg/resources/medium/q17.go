@@ -2,19 +2,40 @@ package medium
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateHpa(clientset *kubernetes.Clientset) utils.Result {
-	hpa, err := clientset.AutoscalingV2().HorizontalPodAutoscalers("default").Get(context.TODO(), "mark43", metav1.GetOptions{})
-	passed := err == nil && hpa.Spec.ScaleTargetRef.Name == "mark43" && *hpa.Spec.MinReplicas == 2 && hpa.Spec.MaxReplicas == 8 && *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization == 80
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         17,
+		Prompt:     "Create a horizontal pod autoscaler hpa-mark43 for deployment mark43 with CPU utilization 80%, min replicas 2 and max replicas 8",
+		Difficulty: registry.Medium,
+		Namespace:  "default",
+		Tags:       []string{"hpa", "scaling"},
+		Scenario:   []string{"klearn-0002-sample.yaml"},
+	}, CreateHpa))
+}
+
+func CreateHpa(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	hpa, err := clientset.AutoscalingV2().HorizontalPodAutoscalers(k8s.Namespace(ctx, "default")).Get(ctx, "mark43", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("horizontal pod autoscaler mark43", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 17 - Create a horizontal pod autoscaler hpa-mark43 for deployment mark43 with CPU utilization 80%, min replicas 2 and max replicas 8",
-		Passed:     passed,
-		Difficulty: "Medium",
+	var utilization interface{}
+	if cpu, ok := utils.FindResourceMetric(hpa.Spec.Metrics, corev1.ResourceCPU); ok {
+		utilization = utils.Value(cpu.Target.AverageUtilization)
+	}
+	return []utils.Criterion{
+		utils.Expect("scale target", "mark43", hpa.Spec.ScaleTargetRef.Name),
+		utils.Expect("min replicas", 2, utils.Value(hpa.Spec.MinReplicas)),
+		utils.Expect("max replicas", 8, hpa.Spec.MaxReplicas),
+		utils.Expect("CPU utilization", 80, utilization),
 	}
 }
@@ -2,21 +2,43 @@ package medium
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func AddSecurityContext(clientset *kubernetes.Clientset) utils.Result {
-	deploy, err := clientset.AppsV1().Deployments("default").Get(context.TODO(), "mark42", metav1.GetOptions{})
-	passed := err == nil && deploy.Spec.Template.Spec.Containers != nil && len(deploy.Spec.Template.Spec.Containers) > 0 &&
-		deploy.Spec.Template.Spec.Containers[0].SecurityContext != nil &&
-		*deploy.Spec.Template.Spec.Containers[0].SecurityContext.AllowPrivilegeEscalation == false
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         18,
+		Prompt:     "Prevent privilege escalation in the deployment mark42",
+		Difficulty: registry.Medium,
+		Namespace:  "default",
+		Tags:       []string{"deployments", "security"},
+		Scenario:   []string{"klearn-0003-sample.yaml"},
+	}, AddSecurityContext))
+}
+
+func AddSecurityContext(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	deploy, err := clientset.AppsV1().Deployments(k8s.Namespace(ctx, "default")).Get(ctx, "mark42", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("deployment mark42", err)}
+	}
+
+	containers := deploy.Spec.Template.Spec.Containers
+	if len(containers) == 0 {
+		return []utils.Criterion{utils.Expect("containers", "at least one", "none")}
+	}
 
-	return utils.Result{
-		TestName:   "Question 18 - Prevent privilege escalation in the deployment mark42",
-		Passed:     passed,
-		Difficulty: "Medium",
+	var criteria []utils.Criterion
+	for _, c := range containers {
+		var allowPrivilegeEscalation interface{}
+		if c.SecurityContext != nil {
+			allowPrivilegeEscalation = utils.Value(c.SecurityContext.AllowPrivilegeEscalation)
+		}
+		criteria = append(criteria, utils.Expect("allowPrivilegeEscalation of container "+c.Name, false, allowPrivilegeEscalation))
 	}
+	return criteria
 }
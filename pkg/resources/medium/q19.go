@@ -2,23 +2,40 @@ package medium
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func AddLivenessProbe(clientset *kubernetes.Clientset) utils.Result {
-	pod, err := clientset.CoreV1().Pods("shield").Get(context.TODO(), "mark50", metav1.GetOptions{})
-	passed := err == nil &&
-		pod.Spec.Containers[0].LivenessProbe.InitialDelaySeconds == 5 &&
-		pod.Spec.Containers[0].LivenessProbe.PeriodSeconds == 10 &&
-		pod.Spec.Containers[0].LivenessProbe.HTTPGet.Path == "/" &&
-		pod.Spec.Containers[0].LivenessProbe.HTTPGet.Port.IntVal == 80
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         19,
+		Prompt:     "Add a liveness probe to the pod mark50 with initial delay 5s, period 10s, HTTP GET, port 80, and path '/' in namespace shield",
+		Difficulty: registry.Medium,
+		Namespace:  "shield",
+		Tags:       []string{"pods", "probes"},
+		Scenario:   []string{"klearn-0004-sample.yaml"},
+	}, AddLivenessProbe))
+}
+
+func AddLivenessProbe(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	pod, err := clientset.CoreV1().Pods(k8s.Namespace(ctx, "shield")).Get(ctx, "mark50", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("pod mark50", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 19 - Add a liveness probe to the pod mark50 with initial delay 5s, period 10s, HTTP GET, port 80, and path '/' in namespace shield",
-		Passed:     passed,
-		Difficulty: "Medium",
+	_, probe, ok := utils.FindLivenessProbe(pod.Spec.Containers)
+	if !ok {
+		return []utils.Criterion{utils.Expect("liveness probe", "set", "none")}
+	}
+	return []utils.Criterion{
+		utils.Expect("initial delay", 5, probe.InitialDelaySeconds),
+		utils.Expect("period", 10, probe.PeriodSeconds),
+		utils.Expect("handler", "httpGet", probe.Handler),
+		utils.Expect("HTTP GET path", "/", probe.Path),
+		utils.Expect("HTTP GET port", 80, probe.Port),
 	}
 }
@@ -2,19 +2,32 @@ package medium
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateDeployment(clientset *kubernetes.Clientset) utils.Result {
-	deployment, err := clientset.AppsV1().Deployments("default").Get(context.TODO(), "nginx-deployment", metav1.GetOptions{})
-	passed := err == nil && deployment.Name == "nginx-deployment" && *deployment.Spec.Replicas == 4 && deployment.Spec.Template.Spec.Containers[0].Image == "nginx:alpine"
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         2,
+		Prompt:     "Create a deployment nginx-deployment with nginx:alpine image and 4 replicas",
+		Difficulty: registry.Medium,
+		Namespace:  "default",
+		Tags:       []string{"deployments"},
+	}, CreateDeployment))
+}
+
+func CreateDeployment(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	deployment, err := clientset.AppsV1().Deployments(k8s.Namespace(ctx, "default")).Get(ctx, "nginx-deployment", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("deployment nginx-deployment", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 2 - Create a deployment nginx-deployment with nginx:alpine image and 4 replicas",
-		Passed:     passed,
-		Difficulty: "Medium",
+	return []utils.Criterion{
+		utils.Expect("replicas", 4, utils.Value(deployment.Spec.Replicas)),
+		utils.ExpectAny("container image", "nginx:alpine", utils.Images(deployment.Spec.Template.Spec.Containers)),
 	}
 }
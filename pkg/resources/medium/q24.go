@@ -2,23 +2,34 @@ package medium
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateJob(clientset *kubernetes.Clientset) utils.Result {
-	job, err := clientset.BatchV1().Jobs("default").Get(context.TODO(), "job-gain", metav1.GetOptions{})
-	passed := err == nil &&
-		*job.Spec.Parallelism == 2 &&
-		*job.Spec.Completions == 4 &&
-		*job.Spec.BackoffLimit == 3 &&
-		*job.Spec.ActiveDeadlineSeconds == 40
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         24,
+		Prompt:     "Create a job job-gain with parallelism 2, completions 4, backoffLimit 3, and deadlineSeconds 40",
+		Difficulty: registry.Medium,
+		Namespace:  "default",
+		Tags:       []string{"jobs"},
+	}, CreateJob))
+}
+
+func CreateJob(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	job, err := clientset.BatchV1().Jobs(k8s.Namespace(ctx, "default")).Get(ctx, "job-gain", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("job job-gain", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 24 - Create a job job-gain with parallelism 2, completions 4, backoffLimit 3, and deadlineSeconds 40",
-		Passed:     passed,
-		Difficulty: "Medium",
+	return []utils.Criterion{
+		utils.Expect("parallelism", 2, utils.Value(job.Spec.Parallelism)),
+		utils.Expect("completions", 4, utils.Value(job.Spec.Completions)),
+		utils.Expect("backoffLimit", 3, utils.Value(job.Spec.BackoffLimit)),
+		utils.Expect("activeDeadlineSeconds", 40, utils.Value(job.Spec.ActiveDeadlineSeconds)),
 	}
 }
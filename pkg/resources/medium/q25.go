@@ -2,23 +2,38 @@ package medium
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateCronjob(clientset *kubernetes.Clientset) utils.Result {
-	cronjob, err := clientset.BatchV1().CronJobs("default").Get(context.TODO(), "cronjob-gain", metav1.GetOptions{})
-	passed := err == nil &&
-		cronjob.Spec.Schedule == "*/5 * * * *" &&
-		cronjob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image == "busybox:1.28" &&
-		cronjob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Command[0] == "sleep 3600" &&
-		cronjob.Spec.JobTemplate.Spec.Template.Spec.RestartPolicy == "Never"
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         25,
+		Prompt:     "Create a cronjob cronjob-gain to run every 5 minutes with image busybox:1.28, command 'sleep 3600', and restartPolicy Never",
+		Difficulty: registry.Medium,
+		Namespace:  "default",
+		Tags:       []string{"cronjobs"},
+	}, CreateCronjob))
+}
+
+func CreateCronjob(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	cronjob, err := clientset.BatchV1().CronJobs(k8s.Namespace(ctx, "default")).Get(ctx, "cronjob-gain", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("cronjob cronjob-gain", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 25 - Create a cronjob cronjob-gain to run every 5 minutes with image busybox:1.28, command 'sleep 3600', and restartPolicy Never",
-		Passed:     passed,
-		Difficulty: "Medium",
+	podSpec := cronjob.Spec.JobTemplate.Spec.Template.Spec
+	criteria := []utils.Criterion{
+		utils.Expect("schedule", "*/5 * * * *", cronjob.Spec.Schedule),
+		utils.ExpectAny("container image", "busybox:1.28", utils.Images(podSpec.Containers)),
+		utils.Expect("restart policy", "Never", podSpec.RestartPolicy),
+	}
+	if container, ok := utils.FindContainerByImage(podSpec.Containers, "busybox:1.28"); ok {
+		criteria = append(criteria, utils.Expect("container command", "sleep 3600", utils.CommandLine(container)))
 	}
+	return criteria
 }
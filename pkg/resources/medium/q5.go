@@ -2,19 +2,31 @@ package medium
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateConfigMap(clientset *kubernetes.Clientset) utils.Result {
-	configMap, err := clientset.CoreV1().ConfigMaps("default").Get(context.TODO(), "europe-configmap", metav1.GetOptions{})
-	passed := err == nil && configMap.Name == "europe-configmap" && configMap.Data["France"] == "Paris"
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         5,
+		Prompt:     "Create a configmap europe-configmap with data France=Paris",
+		Difficulty: registry.Medium,
+		Namespace:  "default",
+		Tags:       []string{"configmaps"},
+	}, CreateConfigMap))
+}
+
+func CreateConfigMap(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	configMap, err := clientset.CoreV1().ConfigMaps(k8s.Namespace(ctx, "default")).Get(ctx, "europe-configmap", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("configmap europe-configmap", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 5 - Create a configmap europe-configmap with data France=Paris",
-		Passed:     passed,
-		Difficulty: "Medium",
+	return []utils.Criterion{
+		utils.Expect("data France", "Paris", configMap.Data["France"]),
 	}
 }
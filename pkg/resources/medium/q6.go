@@ -2,19 +2,32 @@ package medium
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreateLabel(clientset *kubernetes.Clientset) utils.Result {
-	pod, err := clientset.CoreV1().Pods("asia").Get(context.TODO(), "tshoot", metav1.GetOptions{})
-	passed := err == nil && pod.Spec.Containers[0].Image == "amazon/amazon-ecs-network-sidecar:latest" && pod.ObjectMeta.Labels["country"] == "china"
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         6,
+		Prompt:     "Create a pod tshoot with label country=china with amazon/amazon-ecs-network-sidecar:latest image in namespace asia",
+		Difficulty: registry.Medium,
+		Namespace:  "asia",
+		Tags:       []string{"pods", "labels"},
+	}, CreateLabel))
+}
+
+func CreateLabel(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	pod, err := clientset.CoreV1().Pods(k8s.Namespace(ctx, "asia")).Get(ctx, "tshoot", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("pod tshoot", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 6 - Create a pod tshoot with label country=china with amazon/amazon-ecs-network-sidecar:latest image in namespace asia",
-		Passed:     passed,
-		Difficulty: "Medium",
+	return []utils.Criterion{
+		utils.ExpectAny("container image", "amazon/amazon-ecs-network-sidecar:latest", utils.Images(pod.Spec.Containers)),
+		utils.Expect("label country", "china", pod.ObjectMeta.Labels["country"]),
 	}
 }
@@ -2,19 +2,36 @@ package medium
 
 import (
 	"context"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreatePersistentVolume(clientset *kubernetes.Clientset) utils.Result {
-	pv, err := clientset.CoreV1().PersistentVolumes().Get(context.TODO(), "unicorn-pv", metav1.GetOptions{})
-	passed := err == nil && pv.Spec.Capacity.Storage().String() == "1Gi" && pv.Spec.AccessModes[0] == "ReadWriteMany" && pv.Spec.HostPath.Path == "/tmp/data"
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         7,
+		Prompt:     "Create a persistent volume unicorn-pv with capacity 1Gi, access mode ReadWriteMany, and host path /tmp/data",
+		Difficulty: registry.Medium,
+		Tags:       []string{"persistentvolumes", "storage"},
+	}, CreatePersistentVolume))
+}
+
+func CreatePersistentVolume(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	pv, err := clientset.CoreV1().PersistentVolumes().Get(ctx, "unicorn-pv", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("persistent volume unicorn-pv", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 7 - Create a persistent volume unicorn-pv with capacity 1Gi, access mode ReadWriteMany, and host path /tmp/data",
-		Passed:     passed,
-		Difficulty: "Medium",
+	var hostPath interface{}
+	if pv.Spec.HostPath != nil {
+		hostPath = pv.Spec.HostPath.Path
+	}
+	return []utils.Criterion{
+		utils.Expect("capacity", "1Gi", pv.Spec.Capacity.Storage().String()),
+		utils.ExpectAny("access mode", corev1.ReadWriteMany, pv.Spec.AccessModes),
+		utils.Expect("host path", "/tmp/data", hostPath),
 	}
 }
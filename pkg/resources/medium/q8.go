@@ -2,19 +2,33 @@ package medium
 
 import (
 	"context"
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
 	"kubelearn/pkg/utils"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-func CreatePersistentVolumeClaim(clientset *kubernetes.Clientset) utils.Result {
-	pvc, err := clientset.CoreV1().PersistentVolumeClaims("default").Get(context.TODO(), "unicorn-pvc", metav1.GetOptions{})
-	passed := err == nil && pvc.Spec.Resources.Requests.Storage().String() == "400Mi" && pvc.Spec.AccessModes[0] == "ReadWriteMany"
+func init() {
+	registry.Register(registry.New(registry.Meta{
+		ID:         8,
+		Prompt:     "Create a persistent volume claim unicorn-pvc with capacity 400Mi and access mode ReadWriteMany",
+		Difficulty: registry.Medium,
+		Namespace:  "default",
+		Tags:       []string{"persistentvolumeclaims", "storage"},
+	}, CreatePersistentVolumeClaim))
+}
+
+func CreatePersistentVolumeClaim(ctx context.Context, clientset kubernetes.Interface) []utils.Criterion {
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(k8s.Namespace(ctx, "default")).Get(ctx, "unicorn-pvc", metav1.GetOptions{})
+	if err != nil {
+		return []utils.Criterion{utils.Missing("persistent volume claim unicorn-pvc", err)}
+	}
 
-	return utils.Result{
-		TestName:   "Question 8 - Create a persistent volume claim unicorn-pvc with capacity 400Mi and access mode ReadWriteMany",
-		Passed:     passed,
-		Difficulty: "Medium",
+	return []utils.Criterion{
+		utils.Expect("requested storage", "400Mi", pvc.Spec.Resources.Requests.Storage().String()),
+		utils.ExpectAny("access mode", corev1.ReadWriteMany, pvc.Spec.AccessModes),
 	}
 }
@@ -0,0 +1,124 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"kubelearn/pkg/k8s"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+)
+
+// shell starts bash when the image has it and sh otherwise.
+var shell = []string{"sh", "-c", "command -v bash >/dev/null && exec bash -l || exec sh -l"}
+
+// Process is a shell running in a terminal pod, attached through the exec
+// API with a TTY.
+type Process struct {
+	stdin  *io.PipeWriter
+	stdout *io.PipeReader
+	sizes  chan remotecommand.TerminalSize
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+func startExec(clients *k8s.Clients, namespace, pod string, env []string, cols, rows uint16) (*Process, error) {
+	command := shell
+	if len(env) > 0 {
+		command = append(append([]string{"env"}, env...), shell...)
+	}
+	req := clients.Clientset().CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: "terminal",
+			Command:   command,
+			Stdin:     true,
+			Stdout:    true,
+			TTY:       true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(clients.Config(), "POST", req.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	stdinR, stdinW := io.Pipe()
+	stdoutR, stdoutW := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Process{
+		stdin:  stdinW,
+		stdout: stdoutR,
+		sizes:  make(chan remotecommand.TerminalSize, 1),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	p.sizes <- remotecommand.TerminalSize{Width: cols, Height: rows}
+
+	go func() {
+		defer close(p.done)
+		p.err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+			Stdin:             stdinR,
+			Stdout:            stdoutW,
+			Tty:               true,
+			TerminalSizeQueue: p,
+		})
+		stdoutW.Close()
+	}()
+	return p, nil
+}
+
+func (p *Process) Read(b []byte) (int, error)  { return p.stdout.Read(b) }
+func (p *Process) Write(b []byte) (int, error) { return p.stdin.Write(b) }
+
+// Resize queues the new terminal size, replacing a size that was not sent
+// yet.
+func (p *Process) Resize(cols, rows uint16) error {
+	size := remotecommand.TerminalSize{Width: cols, Height: rows}
+	for {
+		select {
+		case p.sizes <- size:
+			return nil
+		default:
+		}
+		select {
+		case <-p.sizes:
+		default:
+		}
+	}
+}
+
+// Next implements remotecommand.TerminalSizeQueue.
+func (p *Process) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-p.sizes:
+		return &size
+	case <-p.done:
+		return nil
+	}
+}
+
+// Wait waits for the shell to exit and returns its exit code.
+func (p *Process) Wait() (int, error) {
+	<-p.done
+	var exitErr exec.ExitError
+	if errors.As(p.err, &exitErr) {
+		return exitErr.ExitStatus(), nil
+	}
+	if p.err != nil {
+		return -1, p.err
+	}
+	return 0, nil
+}
+
+// Close ends the exec session, which stops the shell.
+func (p *Process) Close() error {
+	p.cancel()
+	return p.stdin.Close()
+}
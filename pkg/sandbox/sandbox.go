@@ -0,0 +1,539 @@
+package sandbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// DefaultNamespace holds the terminal pods and their service accounts.
+	DefaultNamespace = "kubelearn-sandbox"
+	// DefaultImage provides kubectl and a shell in the terminal pods.
+	DefaultImage = "bitnami/kubectl:1.27"
+
+	// learnerClusterRole grants the few cluster-scoped permissions some
+	// questions need, such as creating a namespace or a persistent volume.
+	learnerClusterRole = "kubelearn-learner"
+	// namespacePolicy limits the namespaces learners may create to the
+	// namespaces the questions ask them to create, with their own prefix.
+	namespacePolicy = "kubelearn-learner-namespaces"
+	// volumePolicy limits the persistent volumes learners may write to
+	// those of the exercises.
+	volumePolicy = "kubelearn-learner-volumes"
+	// namespaceRole is the built-in role granted in every exercise
+	// namespace; unlike edit it includes roles and network policies.
+	namespaceRole = "admin"
+
+	kubeconfigPath = "/etc/kubelearn"
+	readyTimeout   = 2 * time.Minute
+)
+
+// ErrShared is returned for terminals in exercise namespaces that are not
+// mapped per user; learners administer their exercise namespaces, so they
+// must not share them.
+var ErrShared = errors.New("sandbox terminals need per-user exercise namespaces")
+
+// exerciseVolumes maps the persistent volumes learners create to their host
+// paths: the volume of question 7.
+var exerciseVolumes = map[string]string{"unicorn-pv": "/tmp/data"}
+
+// validatingAdmissionPolicies is served by Kubernetes 1.30 and later.
+var validatingAdmissionPolicies = schema.GroupVersionResource{
+	Group:    "admissionregistration.k8s.io",
+	Version:  "v1",
+	Resource: "validatingadmissionpolicies",
+}
+
+var validatingAdmissionPolicyBindings = schema.GroupVersionResource{
+	Group:    "admissionregistration.k8s.io",
+	Version:  "v1",
+	Resource: "validatingadmissionpolicybindings",
+}
+
+// Options configures the sandbox.
+type Options struct {
+	// Namespace holds the terminal pods. DefaultNamespace is used when it
+	// is empty.
+	Namespace string
+	// Image is the container image of the terminal pods. DefaultImage is
+	// used when it is empty.
+	Image string
+}
+
+// Manager runs learner terminals in pods inside the cluster instead of on
+// the backend host. Each learner gets a pod and a service account that may
+// only change their own exercise namespaces.
+type Manager struct {
+	clients *k8s.Clients
+	opts    Options
+
+	mu sync.Mutex
+	// locks serializes the preparation of each learner's pod.
+	locks map[string]*sync.Mutex
+}
+
+// New returns a sandbox manager that creates its pods with clients.
+func New(clients *k8s.Clients, opts Options) *Manager {
+	if opts.Namespace == "" {
+		opts.Namespace = DefaultNamespace
+	}
+	if opts.Image == "" {
+		opts.Image = DefaultImage
+	}
+	return &Manager{clients: clients, opts: opts, locks: map[string]*sync.Mutex{}}
+}
+
+// Start opens a shell in the terminal pod of user, creating the pod and its
+// permissions first if needed. env is added to the environment of the
+// shell. The exercise namespaces are mapped for ctx.
+func (m *Manager) Start(ctx context.Context, user string, env []string, cols, rows uint16) (*Process, error) {
+	pod, err := m.ensure(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("preparing sandbox: %w", err)
+	}
+	return startExec(m.clients, m.opts.Namespace, pod, env, cols, rows)
+}
+
+// ensure creates the service account, role bindings, kubeconfig and pod of
+// user unless they exist, and waits for the pod to run. The exercise
+// namespaces mapped for ctx must belong to user.
+func (m *Manager) ensure(ctx context.Context, user string) (string, error) {
+	prefix := k8s.NamespacePrefix(ctx)
+	if prefix == "" {
+		return "", ErrShared
+	}
+	if err := k8s.CheckNamespacePrefix(prefix); err != nil {
+		return "", err
+	}
+
+	name := "learner"
+	if user != "" {
+		name = "learner-" + user
+	}
+	unlock := m.lock(name)
+	defer unlock()
+	ns := m.opts.Namespace
+	cs := m.clients.Clientset()
+
+	if err := create(func() error {
+		_, err := cs.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}, metav1.CreateOptions{})
+		return err
+	}); err != nil {
+		return "", err
+	}
+	if err := create(func() error {
+		_, err := cs.CoreV1().ServiceAccounts(ns).Create(ctx, &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: name}}, metav1.CreateOptions{})
+		return err
+	}); err != nil {
+		return "", err
+	}
+
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: ns}}
+	namespaces := ExerciseNamespaces()
+	for _, exercise := range namespaces {
+		if err := m.clients.EnsureNamespace(ctx, exercise); err != nil {
+			return "", err
+		}
+		// Learners administer their namespaces, so keep them from running
+		// privileged pods that could escape to the node.
+		_, err := cs.CoreV1().Namespaces().Patch(ctx, k8s.Namespace(ctx, exercise), types.MergePatchType,
+			[]byte(`{"metadata":{"labels":{"pod-security.kubernetes.io/enforce":"baseline"}}}`), metav1.PatchOptions{})
+		if err != nil {
+			return "", err
+		}
+		binding := &rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: namespaceRole},
+			Subjects:   subjects,
+		}
+		if err := create(func() error {
+			_, err := cs.RbacV1().RoleBindings(k8s.Namespace(ctx, exercise)).Create(ctx, binding, metav1.CreateOptions{})
+			return err
+		}); err != nil {
+			return "", err
+		}
+	}
+
+	created, err := m.ensureNamespacePolicy(ctx)
+	if err != nil {
+		return "", err
+	}
+	volumes, err := m.ensureVolumePolicy(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := m.ensureLearnerRole(ctx, learnerRole(created, volumes)); err != nil {
+		return "", err
+	}
+	if err := create(func() error {
+		_, err := cs.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: learnerClusterRole + "-" + name},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: learnerClusterRole},
+			Subjects:   subjects,
+		}, metav1.CreateOptions{})
+		return err
+	}); err != nil {
+		return "", err
+	}
+
+	if err := create(func() error {
+		_, err := cs.CoreV1().ConfigMaps(ns).Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-kubeconfig"},
+			Data:       map[string]string{"config": kubeconfig(k8s.Namespace(ctx, metav1.NamespaceDefault))},
+		}, metav1.CreateOptions{})
+		return err
+	}); err != nil {
+		return "", err
+	}
+	if err := create(func() error {
+		_, err := cs.CoreV1().Pods(ns).Create(ctx, m.pod(name), metav1.CreateOptions{})
+		return err
+	}); err != nil {
+		return "", err
+	}
+
+	err = wait.PollUntilContextTimeout(ctx, time.Second, readyTimeout, true, func(ctx context.Context) (bool, error) {
+		pod, err := cs.CoreV1().Pods(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		switch pod.Status.Phase {
+		case corev1.PodRunning:
+			return true, nil
+		case corev1.PodSucceeded, corev1.PodFailed:
+			// Start over with a fresh pod on the next connection.
+			cs.CoreV1().Pods(ns).Delete(ctx, name, metav1.DeleteOptions{})
+			return false, fmt.Errorf("terminal pod %s stopped", name)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("waiting for terminal pod %s: %w", name, err)
+	}
+	return name, nil
+}
+
+// lock locks the preparation of the pod name and returns its unlock
+// function, so that learners do not wait for each other's pods.
+func (m *Manager) lock(name string) func() {
+	m.mu.Lock()
+	l, ok := m.locks[name]
+	if !ok {
+		l = &sync.Mutex{}
+		m.locks[name] = l
+	}
+	m.mu.Unlock()
+	l.Lock()
+	return l.Unlock
+}
+
+// ensureLearnerRole creates the learner cluster role, or updates it to role.
+func (m *Manager) ensureLearnerRole(ctx context.Context, role *rbacv1.ClusterRole) error {
+	roles := m.clients.Clientset().RbacV1().ClusterRoles()
+	_, err := roles.Create(ctx, role, metav1.CreateOptions{})
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	current, err := roles.Get(ctx, role.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(current.Rules, role.Rules) {
+		return nil
+	}
+	current.Rules = role.Rules
+	_, err = roles.Update(ctx, current, metav1.UpdateOptions{})
+	return err
+}
+
+// ensureNamespacePolicy creates the admission policy that keeps the
+// learners of the sandbox to creating the namespaces the questions ask for,
+// prefixed with their own username, and reports whether learners may create
+// namespaces. They may not on clusters without validating admission
+// policies, as they could take the namespaces of other learners.
+func (m *Manager) ensureNamespacePolicy(ctx context.Context) (bool, error) {
+	names := CreatedNamespaces()
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, fmt.Sprintf("%q", name))
+	}
+	return m.ensurePolicy(ctx, namespacePolicy, "namespaces", []interface{}{
+		map[string]interface{}{
+			"name":       "prefix",
+			"expression": fmt.Sprintf("request.userInfo.username.substring(%d)", len(m.learnerUsernamePrefix())),
+		},
+		map[string]interface{}{
+			"name":       "names",
+			"expression": "[" + strings.Join(quoted, ", ") + "]",
+		},
+	}, map[string]interface{}{
+		"expression": `variables.names.exists(name, object.metadata.name == variables.prefix + "-" + name)`,
+		"message":    "learners may only create the namespaces " + strings.Join(names, ", ") + " prefixed with their username",
+	})
+}
+
+// ensureVolumePolicy creates the admission policy that keeps the learners
+// of the sandbox to the exercise volumes and their host paths, and reports
+// whether learners may write persistent volumes. They may not on clusters
+// without validating admission policies, as nothing would stop them from
+// mounting the node's filesystem.
+func (m *Manager) ensureVolumePolicy(ctx context.Context) (bool, error) {
+	names := volumeNames()
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		paths = append(paths, fmt.Sprintf("%q: %q", name, exerciseVolumes[name]))
+	}
+	return m.ensurePolicy(ctx, volumePolicy, "persistentvolumes", []interface{}{
+		map[string]interface{}{
+			"name":       "paths",
+			"expression": "{" + strings.Join(paths, ", ") + "}",
+		},
+	}, map[string]interface{}{
+		"expression": "object.metadata.name in variables.paths && has(object.spec.hostPath) && object.spec.hostPath.path == variables.paths[object.metadata.name]",
+		"message":    "learners may only create the persistent volumes " + strings.Join(names, ", ") + " with the host paths of the exercises",
+	})
+}
+
+// ensurePolicy creates the validating admission policy name and its
+// binding. The policy checks validation on the creates and updates of the
+// core resource by the learners of the sandbox. It reports false on
+// clusters that do not serve validating admission policies.
+func (m *Manager) ensurePolicy(ctx context.Context, name, resource string, variables []interface{}, validation map[string]interface{}) (bool, error) {
+	policy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "admissionregistration.k8s.io/v1",
+		"kind":       "ValidatingAdmissionPolicy",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"failurePolicy": "Fail",
+			"matchConstraints": map[string]interface{}{
+				"resourceRules": []interface{}{map[string]interface{}{
+					"apiGroups":   []interface{}{""},
+					"apiVersions": []interface{}{"v1"},
+					"operations":  []interface{}{"CREATE", "UPDATE"},
+					"resources":   []interface{}{resource},
+				}},
+			},
+			"matchConditions": []interface{}{map[string]interface{}{
+				"name":       "learner",
+				"expression": fmt.Sprintf("%q in request.userInfo.groups", "system:serviceaccounts:"+m.opts.Namespace),
+			}},
+			"variables":   variables,
+			"validations": []interface{}{validation},
+		},
+	}}
+	binding := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "admissionregistration.k8s.io/v1",
+		"kind":       "ValidatingAdmissionPolicyBinding",
+		"metadata":   map[string]interface{}{"name": name},
+		"spec": map[string]interface{}{
+			"policyName":        name,
+			"validationActions": []interface{}{"Deny"},
+		},
+	}}
+
+	dyn := m.clients.Dynamic()
+	err := create(func() error {
+		_, err := dyn.Resource(validatingAdmissionPolicies).Create(ctx, policy, metav1.CreateOptions{})
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := create(func() error {
+		_, err := dyn.Resource(validatingAdmissionPolicyBindings).Create(ctx, binding, metav1.CreateOptions{})
+		return err
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// learnerUsernamePrefix is what the usernames of the terminal pods start
+// with; the username of the learner follows it.
+func (m *Manager) learnerUsernamePrefix() string {
+	return "system:serviceaccount:" + m.opts.Namespace + ":learner-"
+}
+
+// pod returns the terminal pod: an unprivileged container that idles until
+// shells are opened in it with exec.
+func (m *Manager) pod(name string) *corev1.Pod {
+	no := false
+	yes := true
+	uid := int64(1001)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"app.kubernetes.io/name": "kubelearn-terminal"},
+		},
+		Spec: corev1.PodSpec{
+			ServiceAccountName: name,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &yes,
+				RunAsUser:    &uid,
+				RunAsGroup:   &uid,
+				FSGroup:      &uid,
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{{
+				Name:    "terminal",
+				Image:   m.opts.Image,
+				Command: []string{"sleep", "infinity"},
+				Env: []corev1.EnvVar{
+					{Name: "HOME", Value: "/home/learner"},
+					{Name: "KUBECONFIG", Value: kubeconfigPath + "/config"},
+					{Name: "TERM", Value: "xterm-256color"},
+				},
+				WorkingDir: "/home/learner",
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: &no,
+					ReadOnlyRootFilesystem:   &yes,
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("500m"),
+						corev1.ResourceMemory: resource.MustParse("256Mi"),
+					},
+				},
+				VolumeMounts: []corev1.VolumeMount{
+					{Name: "home", MountPath: "/home/learner"},
+					{Name: "tmp", MountPath: "/tmp"},
+					{Name: "kubeconfig", MountPath: kubeconfigPath, ReadOnly: true},
+				},
+			}},
+			Volumes: []corev1.Volume{
+				{Name: "home", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+				{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+				{Name: "kubeconfig", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: name + "-kubeconfig"},
+				}}},
+			},
+		},
+	}
+}
+
+// ExerciseNamespaces returns the namespaces the registered questions use,
+// plus the default namespace, sorted by name.
+func ExerciseNamespaces() []string {
+	seen := map[string]bool{metav1.NamespaceDefault: true}
+	for _, q := range registry.All() {
+		if ns := q.Namespace(); ns != "" {
+			seen[ns] = true
+		}
+	}
+	namespaces := make([]string, 0, len(seen))
+	for ns := range seen {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// CreatedNamespaces returns the namespaces the registered questions ask
+// learners to create, sorted by name.
+func CreatedNamespaces() []string {
+	var namespaces []string
+	for _, q := range registry.All() {
+		if ns := q.CreatedNamespace(); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// learnerRole allows the cluster-scoped parts of the exercises. Everything
+// else is granted per exercise namespace. Namespaces are only created when
+// namespaces is set, and persistent volumes only written when volumes is
+// set, that is when the admission policies restrict them to those of the
+// exercises; only the exercise volumes may be changed or deleted.
+func learnerRole(namespaces, volumes bool) *rbacv1.ClusterRole {
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: learnerClusterRole},
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"get", "list", "watch"}},
+			{APIGroups: []string{""}, Resources: []string{"persistentvolumes"}, Verbs: []string{"get", "list", "watch"}},
+			{APIGroups: []string{"storage.k8s.io"}, Resources: []string{"storageclasses"}, Verbs: []string{"get", "list", "watch"}},
+			{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get", "list", "watch"}},
+		},
+	}
+	if namespaces {
+		role.Rules = append(role.Rules,
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"create"}},
+		)
+	}
+	if volumes {
+		role.Rules = append(role.Rules,
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"persistentvolumes"}, Verbs: []string{"create"}},
+			rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"persistentvolumes"}, ResourceNames: volumeNames(), Verbs: []string{"update", "patch", "delete"}},
+		)
+	}
+	return role
+}
+
+// volumeNames returns the names of the exercise volumes, sorted.
+func volumeNames() []string {
+	names := make([]string, 0, len(exerciseVolumes))
+	for name := range exerciseVolumes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// kubeconfig authenticates kubectl with the token of the pod's service
+// account and defaults to the learner's default namespace.
+func kubeconfig(namespace string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: kubelearn
+  cluster:
+    server: https://kubernetes.default.svc
+    certificate-authority: /var/run/secrets/kubernetes.io/serviceaccount/ca.crt
+users:
+- name: learner
+  user:
+    tokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token
+contexts:
+- name: kubelearn
+  context:
+    cluster: kubelearn
+    user: learner
+    namespace: %s
+current-context: kubelearn
+`, namespace)
+}
+
+// create runs a create call and ignores the error of an object that
+// already exists.
+func create(fn func() error) error {
+	if err := fn(); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package scoring
+
+import (
+	"encoding/json"
+	"os"
+
+	"kubelearn/pkg/registry"
+	"kubelearn/pkg/utils"
+)
+
+// Config controls how check results are turned into a score.
+type Config struct {
+	// Weights maps a difficulty to the points a question of that difficulty
+	// is worth. Difficulties without a weight count as 1.
+	Weights map[string]float64 `json:"weights"`
+	// PassThreshold is the minimum score, in percent, needed to pass.
+	PassThreshold float64 `json:"passThreshold"`
+	// PartialCredit awards a share of the points for each passed criterion
+	// instead of all or nothing.
+	PartialCredit bool `json:"partialCredit"`
+}
+
+// DefaultConfig weighs Hard questions three times as much as Easy ones and
+// uses the 66% pass mark of the CKA and CKAD exams.
+func DefaultConfig() Config {
+	return Config{
+		Weights: map[string]float64{
+			registry.Easy:   1,
+			registry.Medium: 2,
+			registry.Hard:   3,
+		},
+		PassThreshold: 66,
+		PartialCredit: true,
+	}
+}
+
+// LoadConfig reads a JSON scoring configuration. Fields missing from the
+// file keep their default values.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	err = json.Unmarshal(data, &cfg)
+	return cfg, err
+}
+
+// Breakdown is the score of a subset of the questions.
+type Breakdown struct {
+	Earned   float64 `json:"earned"`
+	Possible float64 `json:"possible"`
+	Score    float64 `json:"score"`
+}
+
+func (b *Breakdown) add(earned, possible float64) {
+	b.Earned += earned
+	b.Possible += possible
+	if b.Possible > 0 {
+		b.Score = b.Earned / b.Possible * 100
+	}
+}
+
+// Report is the weighted score of a quiz.
+type Report struct {
+	Score         float64              `json:"score"`
+	Passed        bool                 `json:"passed"`
+	PassThreshold float64              `json:"passThreshold"`
+	ByDifficulty  map[string]Breakdown `json:"byDifficulty"`
+	ByTopic       map[string]Breakdown `json:"byTopic"`
+}
+
+// Score weighs the results by difficulty and breaks the total down by
+// difficulty and by the tags of each question.
+func Score(cfg Config, results []utils.Result) Report {
+	var total Breakdown
+	byDifficulty := map[string]*Breakdown{}
+	byTopic := map[string]*Breakdown{}
+
+	for _, result := range results {
+		weight, ok := cfg.Weights[result.Difficulty]
+		if !ok {
+			weight = 1
+		}
+		earned := weight * credit(cfg, result)
+
+		total.add(earned, weight)
+		breakdown(byDifficulty, result.Difficulty).add(earned, weight)
+		if q, ok := registry.Get(result.ID); ok {
+			for _, tag := range q.Tags() {
+				breakdown(byTopic, tag).add(earned, weight)
+			}
+		}
+	}
+
+	return Report{
+		Score:         total.Score,
+		Passed:        total.Score >= cfg.PassThreshold,
+		PassThreshold: cfg.PassThreshold,
+		ByDifficulty:  flatten(byDifficulty),
+		ByTopic:       flatten(byTopic),
+	}
+}
+
+// credit returns the share of a question's points earned by the result.
+func credit(cfg Config, result utils.Result) float64 {
+	if result.Passed {
+		return 1
+	}
+	if !cfg.PartialCredit || len(result.Criteria) == 0 {
+		return 0
+	}
+	return float64(len(result.Criteria)-len(result.Failed())) / float64(len(result.Criteria))
+}
+
+func breakdown(m map[string]*Breakdown, key string) *Breakdown {
+	if m[key] == nil {
+		m[key] = &Breakdown{}
+	}
+	return m[key]
+}
+
+func flatten(m map[string]*Breakdown) map[string]Breakdown {
+	out := make(map[string]Breakdown, len(m))
+	for k, v := range m {
+		out[k] = *v
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package scoring
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"kubelearn/pkg/registry"
+	"kubelearn/pkg/utils"
+)
+
+// result returns the result of question id with a criterion per entry of
+// passed.
+func result(id int, difficulty string, passed ...bool) utils.Result {
+	r := utils.Result{ID: id, Difficulty: difficulty, Passed: len(passed) > 0}
+	for _, p := range passed {
+		r.Criteria = append(r.Criteria, utils.Criterion{Passed: p})
+		r.Passed = r.Passed && p
+	}
+	return r
+}
+
+// results returns n results of difficulty, the first passed of which pass.
+func results(n, passed int, difficulty string) []utils.Result {
+	var out []utils.Result
+	for i := 0; i < n; i++ {
+		out = append(out, result(i+1, difficulty, i < passed))
+	}
+	return out
+}
+
+func TestScore(t *testing.T) {
+	allOrNothing := DefaultConfig()
+	allOrNothing.PartialCredit = false
+	unweighted := Config{PassThreshold: 66, PartialCredit: true}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		results []utils.Result
+		score   float64
+		passed  bool
+	}{
+		{"no results", DefaultConfig(), nil, 0, false},
+		{"all passed", DefaultConfig(), []utils.Result{result(1, registry.Easy, true), result(2, registry.Hard, true, true)}, 100, true},
+		{"partial credit", DefaultConfig(), []utils.Result{result(1, registry.Easy, true, false, true, false)}, 50, false},
+		{"all or nothing", allOrNothing, []utils.Result{result(1, registry.Easy, true, false, true, false)}, 0, false},
+		{"zero criteria", DefaultConfig(), []utils.Result{result(1, registry.Easy), result(2, registry.Easy, true)}, 50, false},
+		{"weights", DefaultConfig(), []utils.Result{result(1, registry.Easy, false), result(2, registry.Hard, true)}, 75, true},
+		{"partial credit weighted", DefaultConfig(), []utils.Result{result(1, registry.Medium, true, false), result(2, registry.Easy, true)}, 200.0 / 3, true},
+		{"unknown difficulty counts as 1", DefaultConfig(), []utils.Result{result(1, "Expert", true), result(2, registry.Easy, false)}, 50, false},
+		{"no weights", unweighted, []utils.Result{result(1, registry.Easy, false), result(2, registry.Hard, true)}, 50, false},
+		{"two of three pass 66", unweighted, results(3, 2, registry.Easy), 200.0 / 3, true},
+		{"exactly 66", unweighted, results(50, 33, registry.Easy), 66, true},
+		{"65 fails", unweighted, results(20, 13, registry.Easy), 65, false},
+	}
+	for _, tt := range tests {
+		report := Score(tt.cfg, tt.results)
+		if math.Abs(report.Score-tt.score) > 1e-9 || report.Passed != tt.passed {
+			t.Errorf("%s: score %v, passed %v, want %v, %v", tt.name, report.Score, report.Passed, tt.score, tt.passed)
+		}
+		if report.PassThreshold != tt.cfg.PassThreshold {
+			t.Errorf("%s: pass threshold %v, want %v", tt.name, report.PassThreshold, tt.cfg.PassThreshold)
+		}
+	}
+}
+
+func TestScoreBreakdown(t *testing.T) {
+	for _, meta := range []registry.Meta{
+		{ID: 9001, Difficulty: registry.Easy, Tags: []string{"pods"}},
+		{ID: 9002, Difficulty: registry.Hard, Tags: []string{"pods", "storage"}},
+		{ID: 9003, Difficulty: registry.Hard, Tags: []string{"storage"}},
+	} {
+		registry.Register(registry.New(meta, nil))
+	}
+
+	report := Score(DefaultConfig(), []utils.Result{
+		result(9001, registry.Easy, true),
+		result(9002, registry.Hard, true, false),
+		result(9003, registry.Hard, false),
+		// Results of unregistered questions count in no topic.
+		result(9004, registry.Medium, true),
+	})
+
+	wantDifficulty := map[string]Breakdown{
+		registry.Easy:   {Earned: 1, Possible: 1, Score: 100},
+		registry.Medium: {Earned: 2, Possible: 2, Score: 100},
+		registry.Hard:   {Earned: 1.5, Possible: 6, Score: 25},
+	}
+	if !reflect.DeepEqual(report.ByDifficulty, wantDifficulty) {
+		t.Errorf("by difficulty %+v, want %+v", report.ByDifficulty, wantDifficulty)
+	}
+	wantTopic := map[string]Breakdown{
+		"pods":    {Earned: 2.5, Possible: 4, Score: 62.5},
+		"storage": {Earned: 1.5, Possible: 6, Score: 25},
+	}
+	if !reflect.DeepEqual(report.ByTopic, wantTopic) {
+		t.Errorf("by topic %+v, want %+v", report.ByTopic, wantTopic)
+	}
+	if want := 4.5 / 9 * 100; report.Score != want {
+		t.Errorf("score %v, want %v", report.Score, want)
+	}
+}
@@ -0,0 +1,380 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"kubelearn/pkg/k8s"
+	"kubelearn/pkg/registry"
+	"kubelearn/pkg/scoring"
+	"kubelearn/pkg/utils"
+)
+
+// DefaultTimeLimit is the duration of the CKA and CKAD exams.
+const DefaultTimeLimit = 2 * time.Hour
+
+// gradeTimeout bounds the final grading of a session.
+const gradeTimeout = 5 * time.Minute
+
+// State is where a session is in its lifecycle.
+type State string
+
+const (
+	// Active sessions accept check attempts.
+	Active State = "active"
+	// Finished sessions were graded when the learner finished them.
+	Finished State = "finished"
+	// Expired sessions were graded when their time limit ran out.
+	Expired State = "expired"
+)
+
+var (
+	ErrNotFound    = errors.New("session not found")
+	ErrExpired     = errors.New("session time limit exceeded")
+	ErrFinished    = errors.New("session already finished")
+	ErrNotSelected = errors.New("question is not part of the session")
+)
+
+// Attempt is one check of a question during a session.
+type Attempt struct {
+	Question int          `json:"question"`
+	Time     time.Time    `json:"time"`
+	Result   utils.Result `json:"result"`
+}
+
+// Session is a snapshot of a quiz session.
+type Session struct {
+	ID   string `json:"id"`
+	User string `json:"user"`
+	// NamespacePrefix maps the exercise namespaces of the session, see
+	// k8s.Namespace.
+	NamespacePrefix string     `json:"namespacePrefix,omitempty"`
+	State           State      `json:"state"`
+	StartedAt       time.Time  `json:"startedAt"`
+	Deadline        time.Time  `json:"deadline"`
+	TimeLimit       float64    `json:"timeLimitSeconds"`
+	Remaining       float64    `json:"remainingSeconds"`
+	Questions       []int      `json:"questions"`
+	Attempts        []Attempt  `json:"attempts"`
+	FinishedAt      *time.Time `json:"finishedAt,omitempty"`
+	// ClusterVersion is the Kubernetes version the session was graded on.
+	ClusterVersion string          `json:"clusterVersion,omitempty"`
+	Results        []utils.Result  `json:"results,omitempty"`
+	Report         *scoring.Report `json:"report,omitempty"`
+}
+
+// Duration is how long the session ran, or has been running.
+func (s Session) Duration() time.Duration {
+	if s.FinishedAt != nil {
+		return s.FinishedAt.Sub(s.StartedAt)
+	}
+	return time.Since(s.StartedAt)
+}
+
+type session struct {
+	Session
+	timer   *time.Timer
+	grading bool
+	graded  chan struct{}
+}
+
+// Recorder receives every session once it is graded. Sessions it kept
+// without an error are dropped from memory.
+type Recorder func(Session) error
+
+// Archive looks up a session that was dropped after it was recorded.
+type Archive func(id string) (Session, bool)
+
+// Options configures a Manager.
+type Options struct {
+	// Scoring turns the results of a session into its score.
+	Scoring scoring.Config
+	// TimeLimit is how long sessions last. DefaultTimeLimit is used when it
+	// is zero.
+	TimeLimit time.Duration
+	// Recorder, if set, is called with each graded session, e.g. to keep
+	// its results in a history.
+	Recorder Recorder
+	// Archive, if set, finds the sessions the Recorder kept, so they can
+	// still be read and finished once dropped from memory.
+	Archive Archive
+}
+
+// Manager keeps the quiz sessions and grades them against the cluster. The
+// time limit is enforced on the server: once a session's deadline passes it
+// is graded automatically, and its results are frozen.
+type Manager struct {
+	clients   *k8s.Clients
+	scoring   scoring.Config
+	timeLimit time.Duration
+	recorder  Recorder
+	archive   Archive
+
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// NewManager returns a manager that grades sessions with clients.
+func NewManager(clients *k8s.Clients, opts Options) *Manager {
+	if opts.TimeLimit == 0 {
+		opts.TimeLimit = DefaultTimeLimit
+	}
+	return &Manager{
+		clients:   clients,
+		scoring:   opts.Scoring,
+		timeLimit: opts.TimeLimit,
+		recorder:  opts.Recorder,
+		archive:   opts.Archive,
+		sessions:  map[string]*session{},
+	}
+}
+
+// Start begins a session of user over the given question IDs, or over every
+// registered question when none are given. The session is graded in the
+// namespaces mapped for ctx.
+func (m *Manager) Start(ctx context.Context, user string, questions []int) (Session, error) {
+	if len(questions) == 0 {
+		for _, q := range registry.All() {
+			questions = append(questions, q.ID())
+		}
+	}
+	seen := map[int]bool{}
+	for _, id := range questions {
+		if _, ok := registry.Get(id); !ok {
+			return Session{}, fmt.Errorf("question %d not found", id)
+		}
+		if seen[id] {
+			return Session{}, fmt.Errorf("question %d selected twice", id)
+		}
+		seen[id] = true
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Session{}, err
+	}
+	now := time.Now()
+	s := &session{
+		Session: Session{
+			ID:              id,
+			User:            user,
+			NamespacePrefix: k8s.NamespacePrefix(ctx),
+			State:           Active,
+			StartedAt:       now,
+			Deadline:        now.Add(m.timeLimit),
+			TimeLimit:       m.timeLimit.Seconds(),
+			Questions:       questions,
+		},
+		graded: make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	m.sessions[id] = s
+	s.timer = time.AfterFunc(m.timeLimit, func() {
+		m.finish(s, Expired)
+	})
+	snapshot := s.snapshot()
+	m.mu.Unlock()
+
+	return snapshot, nil
+}
+
+// Get returns the session with the given ID, from memory or else from the
+// archive.
+func (m *Manager) Get(id string) (Session, error) {
+	m.mu.Lock()
+	s, ok := m.sessions[id]
+	var snapshot Session
+	if ok {
+		snapshot = s.snapshot()
+	}
+	m.mu.Unlock()
+
+	if ok {
+		return snapshot, nil
+	}
+	return m.archived(id)
+}
+
+// archived returns a session that was dropped from memory.
+func (m *Manager) archived(id string) (Session, error) {
+	if m.archive == nil {
+		return Session{}, ErrNotFound
+	}
+	s, ok := m.archive(id)
+	if !ok {
+		return Session{}, ErrNotFound
+	}
+	return s, nil
+}
+
+// Submit checks a question of an active session and records the attempt.
+// Checks started after the deadline are rejected.
+func (m *Manager) Submit(ctx context.Context, id string, question int) (Attempt, error) {
+	started := time.Now()
+
+	m.mu.Lock()
+	s, ok := m.sessions[id]
+	if !ok {
+		m.mu.Unlock()
+		archived, err := m.archived(id)
+		if err != nil {
+			return Attempt{}, err
+		}
+		// Archived sessions are graded.
+		if archived.State == Expired {
+			return Attempt{}, ErrExpired
+		}
+		return Attempt{}, ErrFinished
+	}
+	if err := s.accepting(started); err != nil {
+		m.mu.Unlock()
+		return Attempt{}, err
+	}
+	if !contains(s.Questions, question) {
+		m.mu.Unlock()
+		return Attempt{}, ErrNotSelected
+	}
+	m.mu.Unlock()
+
+	ctx = k8s.WithNamespacePrefix(ctx, s.NamespacePrefix)
+	q, _ := registry.Get(question)
+	attempt := Attempt{
+		Question: question,
+		Time:     started,
+		Result:   q.Check(ctx, m.clients),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// The session may have been graded while this check ran; its results
+	// are frozen, so the attempt is not recorded.
+	if s.State != Active || s.grading {
+		return Attempt{}, s.closedErr()
+	}
+	s.Attempts = append(s.Attempts, attempt)
+	return attempt, nil
+}
+
+// Finish grades the selected questions of a session and scores them. Once
+// a session is graded, further calls return the same frozen results.
+func (m *Manager) Finish(id string) (Session, error) {
+	m.mu.Lock()
+	s, ok := m.sessions[id]
+	m.mu.Unlock()
+	if !ok {
+		return m.archived(id)
+	}
+	return m.finish(s, Finished), nil
+}
+
+// finish grades the session unless it is already graded or being graded,
+// in which case it waits for that grading to complete. Once recorded, the
+// session is dropped from memory.
+func (m *Manager) finish(s *session, state State) Session {
+	m.mu.Lock()
+	if s.State != Active || s.grading {
+		m.mu.Unlock()
+		<-s.graded
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return s.snapshot()
+	}
+	s.grading = true
+	s.timer.Stop()
+	// Finishing after the deadline counts as running out of time.
+	if time.Now().After(s.Deadline) {
+		state = Expired
+	}
+	questions := s.Questions
+	prefix := s.NamespacePrefix
+	m.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(k8s.WithNamespacePrefix(context.Background(), prefix), gradeTimeout)
+	defer cancel()
+	var results []utils.Result
+	for _, qid := range questions {
+		if q, ok := registry.Get(qid); ok {
+			results = append(results, q.Check(ctx, m.clients))
+		}
+	}
+	report := scoring.Score(m.scoring, results)
+	var clusterVersion string
+	if version, err := m.clients.Clientset().Discovery().ServerVersion(); err == nil {
+		clusterVersion = version.GitVersion
+	}
+
+	m.mu.Lock()
+	now := time.Now()
+	s.State = state
+	s.FinishedAt = &now
+	s.ClusterVersion = clusterVersion
+	s.Results = results
+	s.Report = &report
+	s.grading = false
+	close(s.graded)
+	snapshot := s.snapshot()
+	m.mu.Unlock()
+
+	if m.recorder != nil && m.recorder(snapshot) == nil {
+		m.mu.Lock()
+		delete(m.sessions, s.ID)
+		m.mu.Unlock()
+	}
+	return snapshot
+}
+
+// accepting reports why the session does not accept an attempt made at t.
+func (s *session) accepting(t time.Time) error {
+	if s.State != Active || s.grading {
+		return s.closedErr()
+	}
+	if t.After(s.Deadline) {
+		return ErrExpired
+	}
+	return nil
+}
+
+func (s *session) closedErr() error {
+	if s.State == Expired || time.Now().After(s.Deadline) {
+		return ErrExpired
+	}
+	return ErrFinished
+}
+
+// snapshot copies the session so it can be used without holding the lock.
+func (s *session) snapshot() Session {
+	out := s.Session
+	out.Questions = append([]int(nil), s.Questions...)
+	out.Attempts = append([]Attempt{}, s.Attempts...)
+	out.Results = append([]utils.Result(nil), s.Results...)
+	if s.State == Active {
+		out.Remaining = time.Until(s.Deadline).Seconds()
+		if out.Remaining < 0 {
+			out.Remaining = 0
+		}
+	}
+	return out
+}
+
+func contains(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
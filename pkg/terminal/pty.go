@@ -0,0 +1,62 @@
+package terminal
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/creack/pty"
+)
+
+// DefaultShell is started when no shell is configured and $SHELL is unset.
+const DefaultShell = "/bin/bash"
+
+// Local is a shell running on the backend host in a pseudo-terminal, so
+// interactive programs such as vi and kubectl edit work.
+type Local struct {
+	cmd *exec.Cmd
+	pty *os.File
+}
+
+// StartLocal starts shell, or $SHELL or DefaultShell when it is empty, in a
+// new pseudo-terminal of the given size. env is added to the environment
+// of the backend.
+func StartLocal(shell string, env []string, cols, rows uint16) (*Local, error) {
+	if shell == "" {
+		shell = os.Getenv("SHELL")
+	}
+	if shell == "" {
+		shell = DefaultShell
+	}
+	cmd := exec.Command(shell)
+	cmd.Env = append(append(os.Environ(), "TERM=xterm-256color"), env...)
+	if home, err := os.UserHomeDir(); err == nil {
+		cmd.Dir = home
+	}
+
+	f, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: cols, Rows: rows})
+	if err != nil {
+		return nil, err
+	}
+	return &Local{cmd: cmd, pty: f}, nil
+}
+
+func (l *Local) Read(p []byte) (int, error)  { return l.pty.Read(p) }
+func (l *Local) Write(p []byte) (int, error) { return l.pty.Write(p) }
+
+// Resize changes the window size of the pseudo-terminal; the shell gets a
+// SIGWINCH.
+func (l *Local) Resize(cols, rows uint16) error {
+	return pty.Setsize(l.pty, &pty.Winsize{Cols: cols, Rows: rows})
+}
+
+// Wait waits for the shell to exit.
+func (l *Local) Wait() (int, error) {
+	return exitCode(l.cmd.Wait())
+}
+
+// Close kills the shell, if it still runs, and releases the
+// pseudo-terminal.
+func (l *Local) Close() error {
+	l.cmd.Process.Kill()
+	return l.pty.Close()
+}
@@ -0,0 +1,317 @@
+package terminal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// marker starts the escape sequences the shell integration prints: OSC
+// sequences with a private number, which terminals ignore. The nonce of the
+// terminal follows, so that the output of programs cannot pass for them,
+// e.g. cat on a file that holds a marker.
+const marker = "\x1b]6973;"
+
+const (
+	// maxMarker bounds a marker sequence, so a stray marker prefix in the
+	// output cannot hold back the output for long.
+	maxMarker = 64 * 1024
+	// checkTimeout is how long input is held after Enter while the shell
+	// has not asked to check the command line, e.g. at a prompt of
+	// another program.
+	checkTimeout = 5 * time.Second
+	// settleTimeout is how long input stays held after a check while the
+	// command runs without coming back to the prompt.
+	settleTimeout = time.Second
+)
+
+// shellInit is run by bash at its first prompt. It binds Enter to a check
+// of the command line that waits for the verdict of the server, a single
+// y, before the line is accepted; a blocked line is cleared instead.
+func shellInit(nonce string) string {
+	return `__kubelearn_check() {
+  printf '\033]6973;` + nonce + `;check;%s\007' "${READLINE_LINE//$'\a'/}"
+  local verdict
+  IFS= read -r -s -n 1 -t 5 verdict
+  if [ "$verdict" != y ]; then READLINE_LINE=; READLINE_POINT=0; fi
+}
+bind -x '"\C-x\C-k": __kubelearn_check'
+bind '"\C-x\C-j": accept-line'
+bind '"\C-m": "\C-x\C-k\C-x\C-j"'
+bind '"\C-j": "\C-x\C-k\C-x\C-j"'`
+}
+
+// NewNonce returns a random nonce for the markers of a terminal.
+func NewNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ShellEnv integrates bash with the terminal when added to the environment
+// of the shell: before each prompt it reports the last command and its
+// exit code, and it has every command line checked before it runs. Its
+// markers carry nonce, which Integrate must be given too. The integration
+// removes itself from the environment, so nested shells run without it,
+// as do other shells.
+func ShellEnv(nonce string) []string {
+	return []string{
+		`PROMPT_COMMAND=__kubelearn_status=$?; if [ -z "$__kubelearn_init" ]; then __kubelearn_init=1; export -n PROMPT_COMMAND; eval "$KUBELEARN_SHELL_INIT"; unset KUBELEARN_SHELL_INIT; fi; printf '\033]6973;` + nonce + `;prompt;%s;%s\007' "$__kubelearn_status" "$(HISTTIMEFORMAT= history 1)"`,
+		"KUBELEARN_SHELL_INIT=" + shellInit(nonce),
+		// Number repeated commands too, so each of them is reported.
+		"HISTCONTROL=",
+	}
+}
+
+// Hooks are called for the command lines of a shell started with
+// ShellEnv.
+type Hooks struct {
+	// Check, if set, is called with each command line before it runs. A
+	// line it returns an error for is not run, and the error is shown in
+	// the terminal.
+	Check func(line string) error
+	// Finished, if set, is called with each command line the shell ran,
+	// once it finished, with its exit code.
+	Finished func(line string, exitCode int)
+}
+
+// Integrate returns a process that calls hooks for the commands of the
+// shell of p, which must have been started with the ShellEnv of nonce. The
+// escape sequences of the integration are removed from the output of p;
+// those without the nonce are dropped unhandled.
+//
+// Input typed after Enter is held until the shell has checked the line and
+// shown the next prompt, so the shell cannot mistake it for the verdict of
+// the check.
+func Integrate(p Process, nonce string, hooks Hooks) Process {
+	return &integrated{Process: p, nonce: nonce, hooks: hooks, last: -1}
+}
+
+type integrated struct {
+	Process
+	nonce string
+	hooks Hooks
+
+	// The output side.
+	rmu     sync.Mutex
+	buf     []byte
+	pending []byte
+	out     []byte
+	// last is the history number of the last reported command. The first
+	// prompt shows the history of an earlier shell, so it only sets last.
+	last int
+	// editing is set from a prompt until a line is checked. Checks are
+	// only asked for at the prompt, so the output of running commands
+	// cannot answer one.
+	editing bool
+
+	// The input side.
+	wmu      sync.Mutex
+	atPrompt bool
+	holding  bool
+	queue    []byte
+	hold     int
+}
+
+func (i *integrated) Read(b []byte) (int, error) {
+	i.rmu.Lock()
+	defer i.rmu.Unlock()
+	for len(i.out) == 0 {
+		if i.buf == nil {
+			i.buf = make([]byte, 32*1024)
+		}
+		n, err := i.Process.Read(i.buf)
+		i.filter(i.buf[:n])
+		if err != nil {
+			// Pass on what is held back; the shell is gone.
+			i.out = append(i.out, i.pending...)
+			i.pending = nil
+			if len(i.out) == 0 {
+				return 0, err
+			}
+			break
+		}
+	}
+	n := copy(b, i.out)
+	i.out = i.out[n:]
+	return n, nil
+}
+
+func (i *integrated) Write(b []byte) (int, error) {
+	i.wmu.Lock()
+	defer i.wmu.Unlock()
+	return len(b), i.write(b)
+}
+
+// write sends input to the shell. At the prompt, it sends input up to
+// Enter and holds the rest.
+func (i *integrated) write(data []byte) error {
+	for len(data) > 0 {
+		if i.holding {
+			i.queue = append(i.queue, data...)
+			return nil
+		}
+		if !i.atPrompt {
+			_, err := i.Process.Write(data)
+			return err
+		}
+		end := bytes.IndexAny(data, "\r\n")
+		if end < 0 {
+			_, err := i.Process.Write(data)
+			return err
+		}
+		if _, err := i.Process.Write(data[:end+1]); err != nil {
+			return err
+		}
+		i.atPrompt = false
+		i.startHold(checkTimeout)
+		data = data[end+1:]
+	}
+	return nil
+}
+
+// startHold holds input until the next prompt, or until timeout passes.
+func (i *integrated) startHold(timeout time.Duration) {
+	i.holding = true
+	i.hold++
+	hold := i.hold
+	time.AfterFunc(timeout, func() {
+		i.wmu.Lock()
+		defer i.wmu.Unlock()
+		if i.holding && i.hold == hold {
+			i.release()
+		}
+	})
+}
+
+// release sends the held input.
+func (i *integrated) release() {
+	i.holding = false
+	queue := i.queue
+	i.queue = nil
+	i.write(queue)
+}
+
+// filter moves the output in data to out, handling and dropping the
+// markers. A marker split across reads is held back until it is complete.
+func (i *integrated) filter(data []byte) {
+	data = append(i.pending, data...)
+	i.pending = nil
+	for len(data) > 0 {
+		start := bytes.Index(data, []byte(marker))
+		if start < 0 {
+			keep := partialPrefix(data, marker)
+			i.out = append(i.out, data[:len(data)-keep]...)
+			i.pending = append(i.pending, data[len(data)-keep:]...)
+			return
+		}
+		i.out = append(i.out, data[:start]...)
+		data = data[start:]
+		end := bytes.IndexByte(data, '\a')
+		if end < 0 {
+			if len(data) > maxMarker {
+				i.out = append(i.out, data...)
+			} else {
+				i.pending = append(i.pending, data...)
+			}
+			return
+		}
+		nonce, rest, _ := strings.Cut(string(data[len(marker):end]), ";")
+		kind, payload, _ := strings.Cut(rest, ";")
+		switch {
+		case nonce != i.nonce:
+		case kind == "check" && i.editing:
+			i.editing = false
+			i.check(payload)
+		case kind == "prompt":
+			i.editing = true
+			i.prompt(payload)
+		}
+		data = data[end+1:]
+	}
+}
+
+// check answers the check of a command line.
+func (i *integrated) check(line string) {
+	verdict := []byte("y")
+	if i.hooks.Check != nil && strings.TrimSpace(line) != "" {
+		if err := i.hooks.Check(line); err != nil {
+			verdict = []byte("n")
+			i.out = append(i.out, "\r\n\x1b[31m"+err.Error()+"\x1b[0m\r\n"...)
+		}
+	}
+
+	i.wmu.Lock()
+	defer i.wmu.Unlock()
+	// The verdict goes ahead of any held input.
+	i.Process.Write(verdict)
+	if i.holding {
+		i.startHold(settleTimeout)
+	}
+}
+
+// prompt handles the report the shell prints before each prompt, of the
+// form <exit code>;<history line>, where the history line is the output of
+// history 1, e.g. "  42  kubectl get pods".
+func (i *integrated) prompt(payload string) {
+	i.wmu.Lock()
+	i.atPrompt = true
+	if i.holding {
+		i.release()
+	}
+	i.wmu.Unlock()
+
+	code, entry, ok := strings.Cut(payload, ";")
+	if !ok {
+		return
+	}
+	exitCode, err := strconv.Atoi(code)
+	if err != nil {
+		return
+	}
+	entry = strings.TrimLeft(entry, " ")
+	if entry == "" && i.last < 0 {
+		// The history of a new shell is empty.
+		i.last = 0
+		return
+	}
+	digits := strings.IndexFunc(entry, func(r rune) bool { return r < '0' || r > '9' })
+	if digits <= 0 {
+		return
+	}
+	number, _ := strconv.Atoi(entry[:digits])
+	// A * marks a history entry edited after it ran.
+	line := strings.TrimLeft(entry[digits:], "* ")
+
+	first := i.last < 0
+	if number == i.last {
+		// The prompt came back without a new command, e.g. after an
+		// empty or blocked line or Ctrl-C.
+		return
+	}
+	i.last = number
+	if !first && line != "" && i.hooks.Finished != nil {
+		i.hooks.Finished(line, exitCode)
+	}
+}
+
+// partialPrefix returns the length of the longest suffix of data that is a
+// prefix of s.
+func partialPrefix(data []byte, s string) int {
+	n := len(s) - 1
+	if n > len(data) {
+		n = len(data)
+	}
+	for ; n > 0; n-- {
+		if bytes.HasSuffix(data, []byte(s[:n])) {
+			return n
+		}
+	}
+	return 0
+}
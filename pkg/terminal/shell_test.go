@@ -0,0 +1,74 @@
+package terminal
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeShell plays back output and keeps the input written to it.
+type fakeShell struct {
+	output io.Reader
+	input  bytes.Buffer
+}
+
+func (f *fakeShell) Read(b []byte) (int, error)     { return f.output.Read(b) }
+func (f *fakeShell) Write(b []byte) (int, error)    { return f.input.Write(b) }
+func (f *fakeShell) Resize(cols, rows uint16) error { return nil }
+func (f *fakeShell) Wait() (int, error)             { return 0, nil }
+func (f *fakeShell) Close() error                   { return nil }
+
+func TestIntegrateMarkers(t *testing.T) {
+	const nonce = "0123abcd"
+	mark := func(nonce, payload string) string { return marker + nonce + ";" + payload + "\a" }
+	output := strings.Join([]string{
+		mark(nonce, "prompt;0;    1  ls"),
+		"$ ",
+		// A program prints markers without the nonce.
+		mark("forged", "prompt;0;    2  kubectl get pods"),
+		mark("forged", "check;kubectl get pods"),
+		mark(nonce, "check;kubectl delete ns kube-system"),
+		mark(nonce, "prompt;0;    1  ls"),
+		mark(nonce, "check;kubectl get pods"),
+		"pods\r\n",
+		// A command runs, so checks are not asked for.
+		mark(nonce, "check;kubectl get ns"),
+		mark(nonce, "prompt;0;    2  kubectl get pods"),
+		"$ ",
+	}, "")
+
+	shell := &fakeShell{output: strings.NewReader(output)}
+	var checked, finished []string
+	p := Integrate(shell, nonce, Hooks{
+		Check: func(line string) error {
+			checked = append(checked, line)
+			if strings.Contains(line, "delete") {
+				return errors.New("denied")
+			}
+			return nil
+		},
+		Finished: func(line string, exitCode int) {
+			finished = append(finished, line)
+		},
+	})
+	shown, err := io.ReadAll(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"kubectl delete ns kube-system", "kubectl get pods"}; !reflect.DeepEqual(checked, want) {
+		t.Errorf("checked %q, want %q", checked, want)
+	}
+	if want := []string{"kubectl get pods"}; !reflect.DeepEqual(finished, want) {
+		t.Errorf("finished %q, want %q", finished, want)
+	}
+	if got := shell.input.String(); got != "ny" {
+		t.Errorf("verdicts %q, want %q", got, "ny")
+	}
+	if want := "$ \r\n\x1b[31mdenied\x1b[0m\r\npods\r\n$ "; string(shown) != want {
+		t.Errorf("output %q, want %q", shown, want)
+	}
+}
@@ -0,0 +1,169 @@
+package terminal
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Message types of the WebSocket protocol. Terminal output is sent to the
+// client as binary frames, which xterm.js writes as is; control messages
+// are JSON text frames.
+const (
+	// TypeInput carries keystrokes from the client in Data. Clients may also
+	// send input as binary frames.
+	TypeInput = "input"
+	// TypeResize tells the server the size of the client's terminal.
+	TypeResize = "resize"
+	// TypeExit tells the client the shell exited with Code.
+	TypeExit = "exit"
+	// TypeError reports a problem to the client in Error.
+	TypeError = "error"
+	// TypePolicy tells the client the terminal policy blocked the command
+	// line in Command, with the reason in Error and the name of the rule
+	// in Rule.
+	TypePolicy = "policy"
+)
+
+// Message is a JSON control message of the terminal protocol.
+type Message struct {
+	Type  string `json:"type"`
+	Data  string `json:"data,omitempty"`
+	Cols  uint16 `json:"cols,omitempty"`
+	Rows  uint16 `json:"rows,omitempty"`
+	Code  *int   `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Command and Rule describe a policy violation.
+	Command string `json:"command,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+}
+
+// Process is an interactive shell attached to a terminal.
+type Process interface {
+	io.ReadWriter
+	// Resize changes the size of the terminal.
+	Resize(cols, rows uint16) error
+	// Wait waits for the shell to exit and returns its exit code.
+	Wait() (int, error)
+	// Close stops the shell.
+	Close() error
+}
+
+// Conn is a WebSocket connection that serializes its writes, since
+// gorilla/websocket supports only one concurrent writer.
+type Conn struct {
+	ws *websocket.Conn
+	mu sync.Mutex
+}
+
+// NewConn wraps a WebSocket connection.
+func NewConn(ws *websocket.Conn) *Conn {
+	return &Conn{ws: ws}
+}
+
+// WriteOutput sends terminal output as a binary frame.
+func (c *Conn) WriteOutput(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteMessage(websocket.BinaryMessage, data)
+}
+
+// WriteMessage sends a control message as a JSON text frame.
+func (c *Conn) WriteMessage(msg Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ws.WriteJSON(msg)
+}
+
+// Close sends a normal close frame, if the connection is still open, and
+// closes it.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	c.ws.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	c.mu.Unlock()
+	return c.ws.Close()
+}
+
+// Serve connects a shell to a WebSocket client until either side goes away.
+// Output is streamed as it is produced; when the shell exits its exit code
+// is sent before the connection is closed.
+func Serve(conn *Conn, p Process) {
+	defer conn.Close()
+	defer p.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := p.Read(buf)
+			if n > 0 {
+				if werr := conn.WriteOutput(buf[:n]); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		<-done
+		code, err := p.Wait()
+		if err != nil {
+			conn.WriteMessage(Message{Type: TypeError, Error: err.Error()})
+		}
+		conn.WriteMessage(Message{Type: TypeExit, Code: &code})
+		conn.Close()
+	}()
+
+	for {
+		kind, data, err := conn.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		if kind == websocket.BinaryMessage {
+			if _, err := p.Write(data); err != nil {
+				return
+			}
+			continue
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			conn.WriteMessage(Message{Type: TypeError, Error: "invalid message: " + err.Error()})
+			continue
+		}
+		switch msg.Type {
+		case TypeInput:
+			if _, err := p.Write([]byte(msg.Data)); err != nil {
+				return
+			}
+		case TypeResize:
+			if err := p.Resize(msg.Cols, msg.Rows); err != nil {
+				log.Printf("Error resizing terminal: %v", err)
+			}
+		default:
+			conn.WriteMessage(Message{Type: TypeError, Error: "unknown message type " + msg.Type})
+		}
+	}
+}
+
+// exitCode extracts the exit code of a finished command.
+func exitCode(err error) (int, error) {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Criterion is a single graded condition of a question, such as the image
+// of a container or the port of a service.
+type Criterion struct {
+	Name     string `json:"name"`
+	Expected string `json:"expected"`
+	Observed string `json:"observed"`
+	Passed   bool   `json:"passed"`
+}
+
+// Expect compares the observed value with the expected one using their
+// string representation.
+func Expect(name string, expected, observed interface{}) Criterion {
+	e, o := fmt.Sprint(expected), fmt.Sprint(observed)
+	return Criterion{Name: name, Expected: e, Observed: o, Passed: e == o}
+}
+
+// ExpectAny passes when the expected value is among the observed ones, so
+// the order of containers, ports or access modes does not matter.
+func ExpectAny[T any](name string, expected T, observed []T) Criterion {
+	e := fmt.Sprint(expected)
+	values := make([]string, 0, len(observed))
+	passed := false
+	for _, o := range observed {
+		v := fmt.Sprint(o)
+		values = append(values, v)
+		passed = passed || v == e
+	}
+	if len(values) == 0 {
+		values = append(values, "none")
+	}
+	return Criterion{Name: name, Expected: e, Observed: strings.Join(values, ", "), Passed: passed}
+}
+
+// Missing reports a resource that could not be fetched.
+func Missing(name string, err error) Criterion {
+	observed := err.Error()
+	if apierrors.IsNotFound(err) {
+		observed = "not found"
+	}
+	return Criterion{Name: name, Expected: "exists", Observed: observed}
+}
+
+// Value dereferences an optional field so it can be passed to Expect.
+func Value[T any](p *T) interface{} {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+// AllPassed reports whether there is at least one criterion and every
+// criterion passed.
+func AllPassed(criteria []Criterion) bool {
+	for _, c := range criteria {
+		if !c.Passed {
+			return false
+		}
+	}
+	return len(criteria) > 0
+}
+
+// Failed returns the criteria of the result that did not pass.
+func (r Result) Failed() []Criterion {
+	var failed []Criterion
+	for _, c := range r.Criteria {
+		if !c.Passed {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"strings"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// FindContainer returns the container with the given name.
+func FindContainer(containers []corev1.Container, name string) (*corev1.Container, bool) {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i], true
+		}
+	}
+	return nil, false
+}
+
+// FindContainerByImage returns the first container running the image.
+func FindContainerByImage(containers []corev1.Container, image string) (*corev1.Container, bool) {
+	for i := range containers {
+		if containers[i].Image == image {
+			return &containers[i], true
+		}
+	}
+	return nil, false
+}
+
+// Images returns the image of every container.
+func Images(containers []corev1.Container) []string {
+	images := make([]string, 0, len(containers))
+	for _, c := range containers {
+		images = append(images, c.Image)
+	}
+	return images
+}
+
+// CommandLine joins the command and arguments of a container, so that
+// ["sleep", "3600"] and ["sleep 3600"] both read "sleep 3600".
+func CommandLine(container *corev1.Container) string {
+	return strings.Join(append(append([]string{}, container.Command...), container.Args...), " ")
+}
+
+// FindServicePort returns the service port with the given number.
+func FindServicePort(ports []corev1.ServicePort, port int32) (*corev1.ServicePort, bool) {
+	for i := range ports {
+		if ports[i].Port == port {
+			return &ports[i], true
+		}
+	}
+	return nil, false
+}
+
+// FindServicePortByName returns the service port with the given name.
+func FindServicePortByName(ports []corev1.ServicePort, name string) (*corev1.ServicePort, bool) {
+	for i := range ports {
+		if ports[i].Name == name {
+			return &ports[i], true
+		}
+	}
+	return nil, false
+}
+
+// ServicePortNumbers returns the number of every service port.
+func ServicePortNumbers(ports []corev1.ServicePort) []int32 {
+	numbers := make([]int32, 0, len(ports))
+	for _, p := range ports {
+		numbers = append(numbers, p.Port)
+	}
+	return numbers
+}
+
+// ResolvePort returns the number of a port that may be given by number or
+// by the name of one of the container's ports.
+func ResolvePort(port intstr.IntOrString, container *corev1.Container) (int32, bool) {
+	if port.Type == intstr.Int {
+		return port.IntVal, true
+	}
+	if container != nil {
+		for _, p := range container.Ports {
+			if p.Name == port.StrVal {
+				return p.ContainerPort, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Probe summarizes a probe whatever its handler type.
+type Probe struct {
+	// Handler is httpGet, tcpSocket, grpc or exec.
+	Handler string
+	// Port is resolved against the container ports when given by name.
+	Port    int32
+	Path    string
+	Command []string
+
+	InitialDelaySeconds int32
+	PeriodSeconds       int32
+}
+
+// DescribeProbe summarizes probe, which belongs to container. It returns
+// false when the probe is not set.
+func DescribeProbe(probe *corev1.Probe, container *corev1.Container) (Probe, bool) {
+	if probe == nil {
+		return Probe{}, false
+	}
+
+	p := Probe{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+	}
+	switch {
+	case probe.HTTPGet != nil:
+		p.Handler = "httpGet"
+		p.Path = probe.HTTPGet.Path
+		p.Port, _ = ResolvePort(probe.HTTPGet.Port, container)
+	case probe.TCPSocket != nil:
+		p.Handler = "tcpSocket"
+		p.Port, _ = ResolvePort(probe.TCPSocket.Port, container)
+	case probe.GRPC != nil:
+		p.Handler = "grpc"
+		p.Port = probe.GRPC.Port
+	case probe.Exec != nil:
+		p.Handler = "exec"
+		p.Command = probe.Exec.Command
+	}
+	return p, true
+}
+
+// FindLivenessProbe returns the first container with a liveness probe and
+// the summary of that probe.
+func FindLivenessProbe(containers []corev1.Container) (*corev1.Container, Probe, bool) {
+	for i := range containers {
+		if p, ok := DescribeProbe(containers[i].LivenessProbe, &containers[i]); ok {
+			return &containers[i], p, true
+		}
+	}
+	return nil, Probe{}, false
+}
+
+// FindVolumeByClaim returns the volume backed by the persistent volume claim.
+func FindVolumeByClaim(volumes []corev1.Volume, claim string) (*corev1.Volume, bool) {
+	for i := range volumes {
+		if pvc := volumes[i].PersistentVolumeClaim; pvc != nil && pvc.ClaimName == claim {
+			return &volumes[i], true
+		}
+	}
+	return nil, false
+}
+
+// FindVolumeMount returns the mount of a container at the given path.
+func FindVolumeMount(container *corev1.Container, path string) (*corev1.VolumeMount, bool) {
+	for i := range container.VolumeMounts {
+		if strings.TrimSuffix(container.VolumeMounts[i].MountPath, "/") == strings.TrimSuffix(path, "/") {
+			return &container.VolumeMounts[i], true
+		}
+	}
+	return nil, false
+}
+
+// ReferencesSecret reports whether a pod uses the secret through a volume,
+// a projected volume or environment variables of any container.
+func ReferencesSecret(spec corev1.PodSpec, secret string) bool {
+	for _, v := range spec.Volumes {
+		if v.Secret != nil && v.Secret.SecretName == secret {
+			return true
+		}
+		if v.Projected != nil {
+			for _, source := range v.Projected.Sources {
+				if source.Secret != nil && source.Secret.Name == secret {
+					return true
+				}
+			}
+		}
+	}
+	for _, c := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+		for _, from := range c.EnvFrom {
+			if from.SecretRef != nil && from.SecretRef.Name == secret {
+				return true
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == secret {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FindResourceMetric returns the autoscaling metric for a resource such as cpu.
+func FindResourceMetric(metrics []autoscalingv2.MetricSpec, resource corev1.ResourceName) (*autoscalingv2.ResourceMetricSource, bool) {
+	for _, m := range metrics {
+		if m.Resource != nil && m.Resource.Name == resource {
+			return m.Resource, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// sidecarPod has a sidecar before the nginx container, which serves on a
+// named port.
+var sidecarPod = corev1.PodSpec{
+	Volumes: []corev1.Volume{
+		{Name: "logs", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{Name: "html", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "unicorn-pvc"}}},
+	},
+	Containers: []corev1.Container{
+		{Name: "logger", Image: "busybox:1.28", Command: []string{"sleep"}, Args: []string{"3600"}},
+		{
+			Name:         "web",
+			Image:        "nginx:alpine",
+			Ports:        []corev1.ContainerPort{{Name: "http", ContainerPort: 80}},
+			VolumeMounts: []corev1.VolumeMount{{Name: "html", MountPath: "/usr/share/nginx/html/"}},
+			LivenessProbe: &corev1.Probe{
+				ProbeHandler:        corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/", Port: intstr.FromString("http")}},
+				InitialDelaySeconds: 5,
+				PeriodSeconds:       10,
+			},
+		},
+	},
+}
+
+func TestFindContainer(t *testing.T) {
+	containers := sidecarPod.Containers
+	if c, ok := FindContainer(containers, "web"); !ok || c != &containers[1] {
+		t.Errorf("FindContainer(web) = %v, %v, want the second container", c, ok)
+	}
+	if c, ok := FindContainerByImage(containers, "nginx:alpine"); !ok || c.Name != "web" {
+		t.Errorf("FindContainerByImage(nginx:alpine) = %v, %v, want web", c, ok)
+	}
+	if _, ok := FindContainerByImage(containers, "nginx"); ok {
+		t.Error("FindContainerByImage(nginx) found a container")
+	}
+	if _, ok := FindContainer(nil, "web"); ok {
+		t.Error("FindContainer found a container in none")
+	}
+	if got, want := Images(containers), []string{"busybox:1.28", "nginx:alpine"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Images = %q, want %q", got, want)
+	}
+	if got := CommandLine(&containers[0]); got != "sleep 3600" {
+		t.Errorf("CommandLine = %q, want %q", got, "sleep 3600")
+	}
+}
+
+func TestFindServicePort(t *testing.T) {
+	// The port of the question is the second one.
+	ports := []corev1.ServicePort{
+		{Name: "metrics", Port: 9090, TargetPort: intstr.FromInt(9090)},
+		{Name: "http", Port: 80, TargetPort: intstr.FromInt(3000)},
+	}
+	if p, ok := FindServicePort(ports, 80); !ok || p.TargetPort.IntValue() != 3000 {
+		t.Errorf("FindServicePort(80) = %v, %v, want the http port", p, ok)
+	}
+	if p, ok := FindServicePortByName(ports, "http"); !ok || p.Port != 80 {
+		t.Errorf("FindServicePortByName(http) = %v, %v, want port 80", p, ok)
+	}
+	if _, ok := FindServicePort(ports, 443); ok {
+		t.Error("FindServicePort(443) found a port")
+	}
+	if got, want := ServicePortNumbers(ports), []int32{9090, 80}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ServicePortNumbers = %v, want %v", got, want)
+	}
+}
+
+func TestResolvePort(t *testing.T) {
+	web := &sidecarPod.Containers[1]
+	tests := []struct {
+		port      intstr.IntOrString
+		container *corev1.Container
+		want      int32
+		ok        bool
+	}{
+		{intstr.FromInt(8080), nil, 8080, true},
+		{intstr.FromString("http"), web, 80, true},
+		{intstr.FromString("https"), web, 0, false},
+		{intstr.FromString("http"), nil, 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := ResolvePort(tt.port, tt.container); got != tt.want || ok != tt.ok {
+			t.Errorf("ResolvePort(%v) = %v, %v, want %v, %v", tt.port.String(), got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDescribeProbe(t *testing.T) {
+	if _, ok := DescribeProbe(nil, nil); ok {
+		t.Error("DescribeProbe(nil) reported a probe")
+	}
+	tcp := &corev1.Probe{ProbeHandler: corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(6379)}}}
+	if p, _ := DescribeProbe(tcp, nil); p.Handler != "tcpSocket" || p.Port != 6379 {
+		t.Errorf("DescribeProbe(tcp) = %+v", p)
+	}
+	exec := &corev1.Probe{ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"cat", "/tmp/healthy"}}}}
+	if p, _ := DescribeProbe(exec, nil); p.Handler != "exec" || !reflect.DeepEqual(p.Command, []string{"cat", "/tmp/healthy"}) {
+		t.Errorf("DescribeProbe(exec) = %+v", p)
+	}
+
+	container, p, ok := FindLivenessProbe(sidecarPod.Containers)
+	want := Probe{Handler: "httpGet", Port: 80, Path: "/", InitialDelaySeconds: 5, PeriodSeconds: 10}
+	if !ok || container.Name != "web" || !reflect.DeepEqual(p, want) {
+		t.Errorf("FindLivenessProbe = %v, %+v, %v, want web, %+v", container, p, ok, want)
+	}
+	if _, _, ok := FindLivenessProbe(sidecarPod.Containers[:1]); ok {
+		t.Error("FindLivenessProbe found a probe on the sidecar")
+	}
+}
+
+func TestFindVolume(t *testing.T) {
+	volume, ok := FindVolumeByClaim(sidecarPod.Volumes, "unicorn-pvc")
+	if !ok || volume.Name != "html" {
+		t.Fatalf("FindVolumeByClaim = %v, %v, want html", volume, ok)
+	}
+	if _, ok := FindVolumeByClaim(sidecarPod.Volumes, "other"); ok {
+		t.Error("FindVolumeByClaim(other) found a volume")
+	}
+	web := &sidecarPod.Containers[1]
+	if mount, ok := FindVolumeMount(web, "/usr/share/nginx/html"); !ok || mount.Name != "html" {
+		t.Errorf("FindVolumeMount = %v, %v, want html", mount, ok)
+	}
+	if _, ok := FindVolumeMount(&sidecarPod.Containers[0], "/usr/share/nginx/html"); ok {
+		t.Error("FindVolumeMount found a mount on the sidecar")
+	}
+}
+
+func TestReferencesSecret(t *testing.T) {
+	tests := []struct {
+		name string
+		spec corev1.PodSpec
+		want bool
+	}{
+		{"none", sidecarPod, false},
+		{"volume", corev1.PodSpec{Volumes: []corev1.Volume{{VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "s"}}}}}, true},
+		{"projected", corev1.PodSpec{Volumes: []corev1.Volume{{VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{
+			Sources: []corev1.VolumeProjection{{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "s"}}}},
+		}}}}}, true},
+		{"envFrom of a sidecar", corev1.PodSpec{Containers: []corev1.Container{{}, {EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "s"}}}}}}}, true},
+		{"env of an init container", corev1.PodSpec{InitContainers: []corev1.Container{{Env: []corev1.EnvVar{{ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "s"}},
+		}}}}}}, true},
+		{"other secret", corev1.PodSpec{Volumes: []corev1.Volume{{VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "t"}}}}}, false},
+	}
+	for _, tt := range tests {
+		if got := ReferencesSecret(tt.spec, "s"); got != tt.want {
+			t.Errorf("%s: ReferencesSecret = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,31 +1,60 @@
 package utils
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
 )
 
 type Result struct {
-	TestName   string
-	Passed     bool
-	Difficulty string
+	ID         int         `json:"id"`
+	TestName   string      `json:"testName"`
+	Passed     bool        `json:"passed"`
+	Difficulty string      `json:"difficulty"`
+	Criteria   []Criterion `json:"criteria"`
 }
 
 func RenderResultsTable(results []Result) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"KubeLearn - Test your knowledge of Kubernetes v0.2.1", "Result", "Difficulty"})
+	WriteResultsTable(os.Stdout, results, true)
+}
+
+// WriteResultsTable writes the results table to w. Pass and fail are only
+// colored when colored is set and color output is enabled.
+func WriteResultsTable(w io.Writer, results []Result, colored bool) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"KubeLearn - Test your knowledge of Kubernetes v0.2.1", "Result", "Difficulty", "Details"})
 	table.SetAutoWrapText(false)
 
 	for _, result := range results {
-		passedStr := color.GreenString("✅ Pass")
+		passedStr, paint := "✅ Pass", color.GreenString
 		if !result.Passed {
-			passedStr = color.RedString("🆘 Fail")
+			passedStr, paint = "🆘 Fail", color.RedString
 		}
-		row := []string{result.TestName, passedStr, result.Difficulty}
+		if colored {
+			passedStr = paint(passedStr)
+		}
+		row := []string{result.TestName, passedStr, result.Difficulty, failedCriteria(result)}
 		table.Append(row)
 	}
 
 	table.Render()
 }
+
+// failedCriteria describes the failed criteria of a result, one per line.
+func failedCriteria(result Result) string {
+	return strings.Join(result.FailureDetails(), "\n")
+}
+
+// FailureDetails describes each failed criterion of the result, e.g.
+// "image: expected nginx:alpine, got nginx".
+func (r Result) FailureDetails() []string {
+	var lines []string
+	for _, c := range r.Failed() {
+		lines = append(lines, fmt.Sprintf("%s: expected %s, got %s", c.Name, c.Expected, c.Observed))
+	}
+	return lines
+}